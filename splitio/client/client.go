@@ -8,7 +8,10 @@ import (
 	"github.com/splitio/go-client/splitio/conf"
 	"github.com/splitio/go-client/splitio/engine/evaluator"
 	"github.com/splitio/go-client/splitio/engine/evaluator/impressionlabels"
+	eventlistener "github.com/splitio/go-client/splitio/eventListener"
 	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	impressionproperties "github.com/splitio/go-client/splitio/impressionProperties"
+	config "github.com/splitio/go-split-commons/conf"
 	"github.com/splitio/go-split-commons/dtos"
 	"github.com/splitio/go-split-commons/provisional"
 	"github.com/splitio/go-split-commons/storage"
@@ -26,6 +29,7 @@ type SplitClient struct {
 	validator          inputValidation
 	factory            *SplitFactory
 	impressionListener *impressionlistener.WrapperImpressionListener
+	eventListener      eventlistener.EventListener
 	impressionManager  provisional.ImpressionManager
 }
 
@@ -35,6 +39,75 @@ type TreatmentResult struct {
 	Config    *string `json:"config"`
 }
 
+// TreatmentDetails struct that includes the Treatment evaluation along with the metadata of the evaluation
+// - Label - Reason behind the treatment, as reported in impressions (ie: "default rule", "killed")
+// - ChangeNumber - Change number of the split used in the evaluation
+// - Timestamp - Time of the evaluation, in milliseconds
+// - Found - Whether the split was found in storage
+// - Killed - Whether the split was killed, returning its default treatment
+// - Fallback - Whether control was returned because the evaluation couldn't be performed
+type TreatmentDetails struct {
+	Treatment    string  `json:"treatment"`
+	Config       *string `json:"config"`
+	Label        string  `json:"label"`
+	ChangeNumber int64   `json:"changeNumber"`
+	Timestamp    int64   `json:"timestamp"`
+	Found        bool    `json:"found"`
+	Killed       bool    `json:"killed"`
+	Fallback     bool    `json:"fallback"`
+}
+
+// newTreatmentDetails builds the details of an evaluation performed at timestamp
+func newTreatmentDetails(result *evaluator.Result, timestamp int64) TreatmentDetails {
+	return TreatmentDetails{
+		Treatment:    result.Treatment,
+		Config:       result.Config,
+		Label:        result.Label,
+		ChangeNumber: result.SplitChangeNumber,
+		Timestamp:    timestamp,
+		Found:        result.Label != impressionlabels.SplitNotFound && result.Label != impressionlabels.ClientNotReady,
+		Killed:       result.Label == impressionlabels.Killed,
+		Fallback:     isFallbackLabel(result.Label),
+	}
+}
+
+// isFallbackLabel returns true if the label reports that control was returned because the evaluation couldn't be
+// performed, rather than because a split defines a treatment named control
+func isFallbackLabel(label string) bool {
+	switch label {
+	case impressionlabels.Exception, impressionlabels.SplitNotFound, impressionlabels.ClientNotReady:
+		return true
+	default:
+		return false
+	}
+}
+
+// newControlDetails builds the details returned when the evaluation couldn't be performed
+func newControlDetails(label string) TreatmentDetails {
+	return TreatmentDetails{
+		Treatment: evaluator.Control,
+		Config:    nil,
+		Label:     label,
+		Timestamp: time.Now().UTC().UnixNano() / int64(time.Millisecond),
+		Fallback:  true,
+	}
+}
+
+// result keeps the treatment and config of the evaluation
+func (d TreatmentDetails) result() TreatmentResult {
+	return TreatmentResult{
+		Treatment: d.Treatment,
+		Config:    d.Config,
+	}
+}
+
+// EvaluationOptions struct used to customize a single evaluation
+// - Properties - Request-scoped metadata (ie: request id, page) attached to the impressions of the evaluation.
+// They're validated with the same rules used for event properties
+type EvaluationOptions struct {
+	Properties map[string]interface{}
+}
+
 // getEvaluationResult calls evaluation for one particular split
 func (c *SplitClient) getEvaluationResult(
 	matchingKey string,
@@ -46,6 +119,9 @@ func (c *SplitClient) getEvaluationResult(
 	if c.isReady() {
 		return c.evaluator.EvaluateFeature(matchingKey, bucketingKey, feature, attributes)
 	}
+	if result, ok := c.getOverrideResult(matchingKey, feature); ok {
+		return result
+	}
 	c.logger.Warning(operation + ": the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
 	return &evaluator.Result{
 		Treatment: evaluator.Control,
@@ -71,6 +147,10 @@ func (c *SplitClient) getEvaluationsResult(
 		Evaluations:      make(map[string]evaluator.Result),
 	}
 	for _, feature := range features {
+		if override, ok := c.getOverrideResult(matchingKey, feature); ok {
+			result.Evaluations[feature] = *override
+			continue
+		}
 		result.Evaluations[feature] = evaluator.Result{
 			Treatment: evaluator.Control,
 			Label:     impressionlabels.ClientNotReady,
@@ -80,6 +160,19 @@ func (c *SplitClient) getEvaluationsResult(
 	return result
 }
 
+// getOverrideResult returns the treatment forced for the key in the factory's overrides, so that overrides apply
+// even before the SDK is ready
+func (c *SplitClient) getOverrideResult(matchingKey string, feature string) (*evaluator.Result, bool) {
+	if c.factory == nil {
+		return nil, false
+	}
+	treatment, config, ok := c.factory.Overrides().Get(feature, matchingKey)
+	if !ok {
+		return nil, false
+	}
+	return &evaluator.Result{Treatment: treatment, Label: impressionlabels.Override, Config: config}, true
+}
+
 // createImpression creates impression to be stored and used by listener
 func (c *SplitClient) createImpression(
 	feature string,
@@ -110,16 +203,69 @@ func (c *SplitClient) createImpression(
 	}
 }
 
+// processImpressions decides which impressions are logged, along with their properties, and which ones are handed
+// to the listener. Every impression goes through the impression manager, so it's counted and gets its previous
+// time. In optimized mode, impressions with properties are deduped by the factory's observer instead, which takes
+// the properties into account, so that properties are never lost
+func (c *SplitClient) processImpressions(
+	impressions []dtos.Impression,
+	properties map[string]interface{},
+) ([]impressionproperties.Impression, []dtos.Impression) {
+	deduped, processed := c.impressionManager.ProcessImpressions(impressions)
+	if len(properties) > 0 && c.factory.cfg.ImpressionsMode == config.ImpressionsModeOptimized {
+		deduped = make([]dtos.Impression, 0, len(processed))
+		for _, impression := range processed {
+			if !c.factory.cfg.Advanced.IsImpressionsDisabled(impression.FeatureName) {
+				deduped = append(deduped, impression)
+			}
+		}
+	}
+
+	forLog, err := impressionproperties.Attach(deduped, properties)
+	if err != nil {
+		c.logger.Error("Error serializing impression properties, logging impressions without them: ", err.Error())
+		forLog, _ = impressionproperties.Attach(deduped, nil)
+	} else if len(properties) > 0 && c.factory.cfg.ImpressionsMode == config.ImpressionsModeOptimized && c.factory.impressionObserver != nil {
+		forLog = c.factory.impressionObserver.Dedupe(forLog)
+	}
+
+	if c.impressionListener == nil {
+		return forLog, nil
+	}
+	return forLog, processed
+}
+
+// logImpressions stores the impressions, along with their properties if the storage is able to keep them
+func (c *SplitClient) logImpressions(impressions []impressionproperties.Impression) {
+	if producer, ok := c.impressions.(impressionproperties.Producer); ok {
+		producer.LogImpressionsWithProperties(impressions)
+		return
+	}
+	plain := make([]dtos.Impression, 0, len(impressions))
+	for _, impression := range impressions {
+		plain = append(plain, impression.Impression)
+	}
+	c.impressions.LogImpressions(plain)
+}
+
 // storeData stores impression, runs listener and stores metrics
-func (c *SplitClient) storeData(impressions []dtos.Impression, attributes map[string]interface{}, metricsLabel string, evaluationTimeNs int64) {
+func (c *SplitClient) storeData(
+	impressions []dtos.Impression,
+	attributes map[string]interface{},
+	properties map[string]interface{},
+	metricsLabel string,
+	evaluationTimeNs int64,
+) {
 	// Store impression
 	if c.impressions != nil {
-		forLog, forListener := c.impressionManager.ProcessImpressions(impressions)
-		c.impressions.LogImpressions(forLog)
+		forLog, forListener := c.processImpressions(impressions, properties)
+		if len(forLog) > 0 {
+			c.logImpressions(forLog)
+		}
 
 		// Custom Impression Listener
 		if c.impressionListener != nil {
-			c.impressionListener.SendDataToClient(forListener, attributes)
+			c.impressionListener.SendDataToClientWithProperties(forListener, attributes, properties)
 		}
 	} else {
 		c.logger.Warning("No impression storage set in client. Not sending impressions!")
@@ -140,14 +286,10 @@ func (c *SplitClient) doTreatmentCall(
 	key interface{},
 	feature string,
 	attributes map[string]interface{},
+	options *EvaluationOptions,
 	operation string,
 	metricsLabel string,
-) (t TreatmentResult) {
-	controlTreatment := TreatmentResult{
-		Treatment: evaluator.Control,
-		Config:    nil,
-	}
-
+) (t TreatmentDetails) {
 	// Set up a guard deferred function to recover if the SDK starts panicking
 	defer func() {
 		if r := recover(); r != nil {
@@ -157,70 +299,97 @@ func (c *SplitClient) doTreatmentCall(
 				"SDK is panicking with the following error", r, "\n",
 				string(debug.Stack()), "\n",
 				"Returning CONTROL", "\n")
-			t = controlTreatment
+			t = newControlDetails(impressionlabels.Exception)
 		}
 	}()
 
 	if c.isDestroyed() {
 		c.logger.Error("Client has already been destroyed - no calls possible")
-		return controlTreatment
+		return newControlDetails("")
 	}
 
 	matchingKey, bucketingKey, err := c.validator.ValidateTreatmentKey(key, operation)
 	if err != nil {
 		c.logger.Error(err.Error())
-		return controlTreatment
+		return newControlDetails("")
 	}
 
 	feature, err = c.validator.ValidateFeatureName(feature, operation)
 	if err != nil {
 		c.logger.Error(err.Error())
-		return controlTreatment
+		return newControlDetails("")
 	}
 
+	properties := c.getImpressionProperties(options, operation)
+
 	evaluationResult := c.getEvaluationResult(matchingKey, bucketingKey, feature, attributes, operation)
 
 	if !c.validator.IsSplitFound(evaluationResult.Label, feature, operation) {
-		return controlTreatment
+		return newControlDetails(impressionlabels.SplitNotFound)
 	}
 
+	impression := c.createImpression(feature, bucketingKey, evaluationResult.Label, matchingKey, evaluationResult.Treatment, evaluationResult.SplitChangeNumber)
 	c.storeData(
-		[]dtos.Impression{c.createImpression(feature, bucketingKey, evaluationResult.Label, matchingKey, evaluationResult.Treatment, evaluationResult.SplitChangeNumber)},
+		[]dtos.Impression{impression},
 		attributes,
+		properties,
 		metricsLabel,
 		evaluationResult.EvaluationTimeNs,
 	)
 
-	return TreatmentResult{
-		Treatment: evaluationResult.Treatment,
-		Config:    evaluationResult.Config,
-	}
+	return newTreatmentDetails(evaluationResult, impression.Time)
 }
 
 // Treatment implements the main functionality of split. Retrieve treatments of a specific feature
 // for a certain key and set of attributes
 func (c *SplitClient) Treatment(key interface{}, feature string, attributes map[string]interface{}) string {
-	return c.doTreatmentCall(key, feature, attributes, "Treatment", "sdk.getTreatment").Treatment
+	return c.doTreatmentCall(key, feature, attributes, nil, "Treatment", "sdk.getTreatment").Treatment
 }
 
 // TreatmentWithConfig implements the main functionality of split. Retrieves the treatment of a specific feature with
 // the corresponding configuration if it is present
 func (c *SplitClient) TreatmentWithConfig(key interface{}, feature string, attributes map[string]interface{}) TreatmentResult {
-	return c.doTreatmentCall(key, feature, attributes, "TreatmentWithConfig", "sdk.getTreatmentWithConfig")
+	return c.doTreatmentCall(key, feature, attributes, nil, "TreatmentWithConfig", "sdk.getTreatmentWithConfig").result()
+}
+
+// TreatmentWithOptions retrieves the treatment of a specific feature with the corresponding configuration if it is
+// present, attaching the properties set in options to the generated impression
+func (c *SplitClient) TreatmentWithOptions(
+	key interface{},
+	feature string,
+	attributes map[string]interface{},
+	options *EvaluationOptions,
+) TreatmentResult {
+	return c.doTreatmentCall(key, feature, attributes, options, "TreatmentWithOptions", "sdk.getTreatmentWithOptions").result()
+}
+
+// TreatmentDetails retrieves the treatment of a specific feature along with the metadata of the evaluation, such as
+// the label, the change number of the split and whether the split was found or killed
+func (c *SplitClient) TreatmentDetails(key interface{}, feature string, attributes map[string]interface{}) TreatmentDetails {
+	return c.doTreatmentCall(key, feature, attributes, nil, "TreatmentDetails", "sdk.getTreatmentDetails")
+}
+
+// getImpressionProperties validates the properties set in options. Invalid properties are discarded
+func (c *SplitClient) getImpressionProperties(options *EvaluationOptions, operation string) map[string]interface{} {
+	if options == nil {
+		return nil
+	}
+	properties, err := c.validator.validateImpressionProperties(options.Properties, operation)
+	if err != nil {
+		return nil
+	}
+	return properties
 }
 
 // Generates control treatments
-func (c *SplitClient) generateControlTreatments(features []string, operation string) map[string]TreatmentResult {
-	treatments := make(map[string]TreatmentResult)
+func (c *SplitClient) generateControlTreatments(features []string, operation string) map[string]TreatmentDetails {
+	treatments := make(map[string]TreatmentDetails)
 	filtered, err := c.validator.ValidateFeatureNames(features, operation)
 	if err != nil {
 		return treatments
 	}
 	for _, feature := range filtered {
-		treatments[feature] = TreatmentResult{
-			Treatment: evaluator.Control,
-			Config:    nil,
-		}
+		treatments[feature] = newControlDetails("")
 	}
 	return treatments
 }
@@ -231,10 +400,11 @@ func (c *SplitClient) doTreatmentsCall(
 	key interface{},
 	features []string,
 	attributes map[string]interface{},
+	options *EvaluationOptions,
 	operation string,
 	metricsLabel string,
-) (t map[string]TreatmentResult) {
-	treatments := make(map[string]TreatmentResult)
+) (t map[string]TreatmentDetails) {
+	treatments := make(map[string]TreatmentDetails)
 
 	// Set up a guard deferred function to recover if the SDK starts panicking
 	defer func() {
@@ -262,36 +432,43 @@ func (c *SplitClient) doTreatmentsCall(
 	filteredFeatures, err := c.validator.ValidateFeatureNames(features, operation)
 	if err != nil {
 		c.logger.Error(err.Error())
-		return map[string]TreatmentResult{}
+		return map[string]TreatmentDetails{}
 	}
 
+	properties := c.getImpressionProperties(options, operation)
+
 	var bulkImpressions []dtos.Impression
 	evaluationsResult := c.getEvaluationsResult(matchingKey, bucketingKey, filteredFeatures, attributes, operation)
 	for feature, evaluation := range evaluationsResult.Evaluations {
 		if !c.validator.IsSplitFound(evaluation.Label, feature, operation) {
-			treatments[feature] = TreatmentResult{
-				Treatment: evaluator.Control,
-				Config:    nil,
-			}
+			treatments[feature] = newControlDetails(impressionlabels.SplitNotFound)
 		} else {
-			bulkImpressions = append(bulkImpressions, c.createImpression(feature, bucketingKey, evaluation.Label, matchingKey, evaluation.Treatment, evaluation.SplitChangeNumber))
+			impression := c.createImpression(feature, bucketingKey, evaluation.Label, matchingKey, evaluation.Treatment, evaluation.SplitChangeNumber)
+			bulkImpressions = append(bulkImpressions, impression)
 
-			treatments[feature] = TreatmentResult{
-				Treatment: evaluation.Treatment,
-				Config:    evaluation.Config,
-			}
+			evaluation := evaluation
+			treatments[feature] = newTreatmentDetails(&evaluation, impression.Time)
 		}
 	}
 
-	c.storeData(bulkImpressions, attributes, metricsLabel, evaluationsResult.EvaluationTimeNs)
+	c.storeData(bulkImpressions, attributes, properties, metricsLabel, evaluationsResult.EvaluationTimeNs)
 
 	return treatments
 }
 
+// toTreatmentResults keeps the treatment and config of each evaluation
+func toTreatmentResults(details map[string]TreatmentDetails) map[string]TreatmentResult {
+	results := make(map[string]TreatmentResult, len(details))
+	for feature, detail := range details {
+		results[feature] = detail.result()
+	}
+	return results
+}
+
 // Treatments evaluates multiple featers for a single user and set of attributes at once
 func (c *SplitClient) Treatments(key interface{}, features []string, attributes map[string]interface{}) map[string]string {
 	treatments := map[string]string{}
-	result := c.doTreatmentsCall(key, features, attributes, "Treatments", "sdk.getTreatments")
+	result := c.doTreatmentsCall(key, features, attributes, nil, "Treatments", "sdk.getTreatments")
 	for feature, treatmentResult := range result {
 		treatments[feature] = treatmentResult.Treatment
 	}
@@ -300,7 +477,24 @@ func (c *SplitClient) Treatments(key interface{}, features []string, attributes
 
 // TreatmentsWithConfig evaluates multiple featers for a single user and set of attributes at once and returns configurations
 func (c *SplitClient) TreatmentsWithConfig(key interface{}, features []string, attributes map[string]interface{}) map[string]TreatmentResult {
-	return c.doTreatmentsCall(key, features, attributes, "TreatmentsWithConfig", "sdk.getTreatmentsWithConfig")
+	return toTreatmentResults(c.doTreatmentsCall(key, features, attributes, nil, "TreatmentsWithConfig", "sdk.getTreatmentsWithConfig"))
+}
+
+// TreatmentsWithOptions evaluates multiple features for a single user and set of attributes at once and returns
+// configurations, attaching the properties set in options to the generated impressions
+func (c *SplitClient) TreatmentsWithOptions(
+	key interface{},
+	features []string,
+	attributes map[string]interface{},
+	options *EvaluationOptions,
+) map[string]TreatmentResult {
+	return toTreatmentResults(c.doTreatmentsCall(key, features, attributes, options, "TreatmentsWithOptions", "sdk.getTreatmentsWithOptions"))
+}
+
+// TreatmentsDetails evaluates multiple features for a single user and set of attributes at once and returns the
+// metadata of each evaluation
+func (c *SplitClient) TreatmentsDetails(key interface{}, features []string, attributes map[string]interface{}) map[string]TreatmentDetails {
+	return c.doTreatmentsCall(key, features, attributes, nil, "TreatmentsDetails", "sdk.getTreatmentsDetails")
 }
 
 // isDestroyed returns true if the client has been destroyed
@@ -367,24 +561,47 @@ func (c *SplitClient) Track(
 		return err
 	}
 
-	err = c.events.Push(dtos.EventDTO{
+	event := dtos.EventDTO{
 		Key:             key,
 		TrafficTypeName: trafficType,
 		EventTypeID:     eventType,
 		Value:           value,
 		Timestamp:       time.Now().UTC().UnixNano() / int64(time.Millisecond), // Convert standard timestamp to java's ms timestamps
 		Properties:      properties,
-	}, size)
+	}
+	err = c.events.Push(event, size)
 
 	if err != nil {
 		c.logger.Error("Error tracking event", err.Error())
 		return err
 	}
 
+	if c.eventListener != nil {
+		c.notifyEventListener(event)
+	}
+
 	return nil
 }
 
+// notifyEventListener hands a tracked event to the event listener, guarding Track against a panicking listener
+func (c *SplitClient) notifyEventListener(event dtos.EventDTO) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Error(
+				"Event listener is panicking with the following error", r, "\n",
+				string(debug.Stack()), "\n",
+			)
+		}
+	}()
+	c.eventListener.LogEvent(event)
+}
+
 // BlockUntilReady Calls BlockUntilReady on factory to block client on readiness
 func (c *SplitClient) BlockUntilReady(timer int) error {
 	return c.factory.BlockUntilReady(timer)
 }
+
+// BlockUntilReadyTimeout Calls BlockUntilReadyTimeout on factory to block client on readiness
+func (c *SplitClient) BlockUntilReadyTimeout(timeout time.Duration) error {
+	return c.factory.BlockUntilReadyTimeout(timeout)
+}
@@ -1,13 +1,20 @@
 package client
 
 import (
+	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -17,7 +24,11 @@ import (
 	"github.com/splitio/go-client/splitio/engine/evaluator"
 	"github.com/splitio/go-client/splitio/engine/evaluator/impressionlabels"
 	evaluatorMock "github.com/splitio/go-client/splitio/engine/evaluator/mocks"
+	filesink "github.com/splitio/go-client/splitio/fileSink"
 	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	impressionproperties "github.com/splitio/go-client/splitio/impressionProperties"
+	"github.com/splitio/go-client/splitio/localhost"
+	uniquekeys "github.com/splitio/go-client/splitio/uniqueKeys"
 	commonsCfg "github.com/splitio/go-split-commons/conf"
 	"github.com/splitio/go-split-commons/dtos"
 	"github.com/splitio/go-split-commons/provisional"
@@ -238,6 +249,310 @@ func TestLocalhostMode(t *testing.T) {
 	os.Remove(file.Name())
 }
 
+func TestLocalhostModeYAMLWithRules(t *testing.T) {
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/splits_with_rules.yaml"
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	defer client.Destroy()
+
+	expectedTreatment(client.Treatment("qa_user", "checkout_flow", nil), "on", t)
+	expectedTreatment(client.Treatment("user1", "checkout_flow", map[string]interface{}{"age": 21, "country": "ar"}), "on", t)
+	expectedTreatment(client.Treatment("user1", "checkout_flow", map[string]interface{}{"age": 16, "country": "ar"}), "off", t)
+	expectedTreatment(client.Treatment("user1", "checkout_flow", nil), "off", t)
+	expectedTreatment(client.Treatment("user1", "legacy_banner", nil), "hidden", t)
+
+	result := client.TreatmentWithConfig("qa_user", "checkout_flow", nil)
+	if result.Config == nil || *result.Config != "{\"color\": \"blue\"}" {
+		t.Error("Config should be returned for on treatment")
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[client.Treatment(fmt.Sprintf("key%d", i), "new_pricing", nil)]++
+	}
+	if counts["off"] < 400 || counts["off"] > 600 || counts["v1"] < 150 || counts["v2"] < 150 {
+		t.Error("Traffic allocation and weighted treatments should be applied. Actual:", counts)
+	}
+}
+
+func TestLocalhostModeSplitChangesAndSegments(t *testing.T) {
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/localhost/split_changes.json"
+	sdkConf.SegmentDirectory = "../../testdata/localhost/segments"
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	defer client.Destroy()
+
+	expectedTreatment(client.Treatment("user1", "employees_feature", nil), "on", t)
+	expectedTreatment(client.Treatment("user3", "employees_feature", nil), "off", t)
+	expectedTreatment(client.Treatment("user4", "employees_feature", nil), "beta", t)
+}
+
+func TestLocalhostModeOverrides(t *testing.T) {
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/splits_with_rules.yaml"
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	defer client.Destroy()
+
+	config := "{\"forced\": true}"
+	factory.Overrides().Set("checkout_flow", "forced", &config, "user1")
+	factory.Overrides().Set("legacy_banner", "shown", nil)
+	factory.Overrides().Set("unknown_feature", "on", nil)
+
+	details := client.TreatmentDetails("user1", "checkout_flow", nil)
+	if details.Treatment != "forced" || details.Label != "override" || details.Config == nil || *details.Config != config {
+		t.Error("Override should be applied for user1. Actual:", details)
+	}
+	expectedTreatment(client.Treatment("user2", "checkout_flow", nil), "off", t)
+	expectedTreatment(client.Treatment("user2", "legacy_banner", nil), "shown", t)
+	expectedTreatment(client.Treatment("user2", "unknown_feature", nil), "on", t)
+
+	listed := factory.Manager().Overrides()
+	if len(listed) != 3 || listed[0].Feature != "checkout_flow" || len(listed[0].Keys) != 1 || listed[2].Feature != "unknown_feature" {
+		t.Error("Overrides should be listed by the manager. Actual:", listed)
+	}
+
+	factory.Overrides().Clear("legacy_banner")
+	expectedTreatment(client.Treatment("user2", "legacy_banner", nil), "hidden", t)
+	factory.Overrides().ClearAll()
+	expectedTreatment(client.Treatment("user1", "checkout_flow", nil), "off", t)
+	expectedTreatment(client.Treatment("user2", "unknown_feature", nil), "control", t)
+	if len(factory.Manager().Overrides()) != 0 {
+		t.Error("Overrides should be cleared")
+	}
+}
+
+func TestLocalhostModeRecording(t *testing.T) {
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/splits_with_rules.yaml"
+	sdkConf.ImpressionsMode = "debug"
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	defer client.Destroy()
+
+	client.Treatment("qa_user", "checkout_flow", nil)
+	client.Treatments("user1", []string{"checkout_flow", "legacy_banner"}, nil)
+	client.Track("user1", "user", "checkout", nil, nil)
+
+	impressions := factory.Recorder().RecordedImpressions()
+	if len(impressions) != 3 || impressions[0].KeyName != "qa_user" || impressions[0].Treatment != "on" {
+		t.Error("Impressions should be recorded. Actual:", impressions)
+	}
+	events := factory.Recorder().RecordedEvents()
+	if len(events) != 1 || events[0].Key != "user1" || events[0].EventTypeID != "checkout" {
+		t.Error("Events should be recorded. Actual:", events)
+	}
+
+	factory.Recorder().Reset()
+	if len(factory.Recorder().RecordedImpressions()) != 0 || len(factory.Recorder().RecordedEvents()) != 0 {
+		t.Error("Recorded data should be discarded on reset")
+	}
+}
+
+func TestLocalhostModeSplitSources(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testdata/splits.yaml")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sdkConf := conf.Default()
+	sdkConf.SplitReader = bytes.NewReader(data)
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	defer client.Destroy()
+
+	expectedTreatment(client.Treatment("only_key", "my_feature", nil), "off", t)
+	expectedTreatment(client.Treatment("key", "other_feature_3", nil), "off", t)
+	expectedTreatment(client.Treatment("key_whitelist", "other_feature_3", nil), "on", t)
+
+	sdkConf = conf.Default()
+	sdkConf.SplitFS = os.DirFS("../../testdata")
+	sdkConf.SplitFile = "localhost/split_changes.json"
+	sdkConf.SegmentDirectory = "localhost/segments"
+	fsFactory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fsClient := fsFactory.Client()
+	fsClient.BlockUntilReady(1)
+	defer fsClient.Destroy()
+
+	expectedTreatment(fsClient.Treatment("user1", "employees_feature", nil), "on", t)
+	expectedTreatment(fsClient.Treatment("user3", "employees_feature", nil), "off", t)
+}
+
+func TestLocalhostModeSplitFiles(t *testing.T) {
+	sdkConf := conf.Default()
+	sdkConf.SplitFiles = []string{"../../testdata/splits_with_rules.yaml", "../../testdata/localhost/developer_overrides.yaml"}
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	defer client.Destroy()
+
+	expectedTreatment(client.Treatment("qa_user", "checkout_flow", nil), "off", t)
+	expectedTreatment(client.Treatment("user1", "checkout_flow", map[string]interface{}{"age": 21, "country": "ar"}), "on", t)
+	expectedTreatment(client.Treatment("user1", "dev_only_feature", nil), "on", t)
+	expectedTreatment(client.Treatment("user1", "legacy_banner", nil), "hidden", t)
+}
+
+func TestLocalhostModeReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_tests")
+	if err != nil {
+		t.Error("Couldn't create temporary directory for localhost client tests: ", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	splitFile := filepath.Join(dir, "splits.yaml")
+	ioutil.WriteFile(splitFile, []byte("splits:\n  feature1:\n    conditions:\n      - treatment: \"on\"\n"), 0644)
+
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = splitFile
+	sdkConf.TaskPeriods.SplitSyncPeriod = 100 * time.Millisecond
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	updates, _ := factory.LocalhostUpdates()
+
+	expectedTreatment(client.Treatment("key", "feature1", nil), "on", t)
+
+	ioutil.WriteFile(splitFile, []byte("splits:\n  feature1:\n    conditions:\n      - treatment: \"off\"\n"), 0644)
+	select {
+	case update := <-updates:
+		if len(update.Splits) != 1 || update.Splits[0] != "feature1" || update.Err != nil {
+			t.Error("Changed split should be notified. Actual:", update)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("Timed out waiting for the file to be reloaded")
+	}
+	expectedTreatment(client.Treatment("key", "feature1", nil), "off", t)
+
+	client.Destroy()
+	if _, ok := <-updates; ok {
+		t.Error("Updates channel should be closed when destroying the factory")
+	}
+
+	inMemory := SplitFactory{}
+	if _, ok := <-func() <-chan localhost.UpdateEvent { u, _ := inMemory.LocalhostUpdates(); return u }(); ok {
+		t.Error("Updates channel should be closed outside localhost mode")
+	}
+}
+
+func TestTreatmentDetails(t *testing.T) {
+	client := getClient()
+	config := "{\"color\": \"red\"}"
+	client.evaluator = evaluatorMock.MockEvaluator{
+		EvaluateFeatureCall: func(key string, bucketingKey *string, feature string, attributes map[string]interface{}) *evaluator.Result {
+			switch feature {
+			case "killed":
+				return &evaluator.Result{Treatment: "off", Label: impressionlabels.Killed, SplitChangeNumber: 456, Config: &config}
+			case "exception":
+				return &evaluator.Result{Treatment: evaluator.Control, Label: impressionlabels.Exception, SplitChangeNumber: 789}
+			case "control_treatment":
+				return &evaluator.Result{Treatment: evaluator.Control, Label: impressionlabels.NoConditionMatched, SplitChangeNumber: 321}
+			default:
+				return &evaluator.Result{Treatment: evaluator.Control, Label: impressionlabels.SplitNotFound}
+			}
+		},
+		EvaluateFeaturesCall: func(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+			return evaluator.Results{Evaluations: map[string]evaluator.Result{
+				"feature": {Treatment: "on", Label: impressionlabels.NoConditionMatched, SplitChangeNumber: 123},
+				"missing": {Treatment: evaluator.Control, Label: impressionlabels.SplitNotFound},
+			}}
+		},
+	}
+
+	before := time.Now().UTC().UnixNano() / int64(time.Millisecond)
+	details := client.TreatmentDetails("user1", "killed", nil)
+	if details.Treatment != "off" || details.Config == nil || *details.Config != config || details.Label != impressionlabels.Killed || details.ChangeNumber != 456 {
+		t.Error("Unexpected details", details)
+	}
+	if !details.Found || !details.Killed || details.Fallback || details.Timestamp < before {
+		t.Error("Unexpected details", details)
+	}
+
+	details = client.TreatmentDetails("user1", "exception", nil)
+	if details.Treatment != evaluator.Control || details.Label != impressionlabels.Exception || !details.Found || details.Killed || !details.Fallback {
+		t.Error("Unexpected details", details)
+	}
+
+	details = client.TreatmentDetails("user1", "missing", nil)
+	if details.Treatment != evaluator.Control || details.Label != impressionlabels.SplitNotFound || details.Found || !details.Fallback {
+		t.Error("Unexpected details", details)
+	}
+
+	details = client.TreatmentDetails("user1", "control_treatment", nil)
+	if details.Treatment != evaluator.Control || !details.Found || details.Fallback {
+		t.Error("A split defining a control treatment should not be reported as a fallback", details)
+	}
+
+	details = client.TreatmentDetails(nil, "killed", nil)
+	if details.Treatment != evaluator.Control || details.Found || !details.Fallback {
+		t.Error("Unexpected details for an invalid key", details)
+	}
+
+	all := client.TreatmentsDetails("user1", []string{"feature", "missing"}, nil)
+	if all["feature"].Treatment != "on" || all["feature"].Label != impressionlabels.NoConditionMatched || all["feature"].ChangeNumber != 123 || !all["feature"].Found || all["feature"].Fallback {
+		t.Error("Unexpected details", all["feature"])
+	}
+	if all["missing"].Treatment != evaluator.Control || all["missing"].Found || !all["missing"].Fallback {
+		t.Error("Unexpected details", all["missing"])
+	}
+}
+
+func TestOverridesApplyBeforeReady(t *testing.T) {
+	client := getClient()
+	client.factory.status.Store(sdkStatusInitializing)
+	client.factory.Overrides().Set("feature", "forced", nil, "user1")
+
+	details := client.TreatmentDetails("user1", "feature", nil)
+	if details.Treatment != "forced" || details.Label != impressionlabels.Override || details.Fallback {
+		t.Error("Overrides should apply before the SDK is ready. Actual:", details)
+	}
+	all := client.TreatmentsDetails("user1", []string{"feature", "other"}, nil)
+	if all["feature"].Treatment != "forced" || all["other"].Label != impressionlabels.ClientNotReady {
+		t.Error("Only overridden features should be resolved before the SDK is ready. Actual:", all)
+	}
+	if client.Treatment("user2", "feature", nil) != evaluator.Control {
+		t.Error("Keys without overrides should get control before the SDK is ready")
+	}
+}
+
 func TestClientGetTreatmentConsideringValidationInputs(t *testing.T) {
 	factory := getFactory()
 	client := factory.Client()
@@ -358,6 +673,7 @@ func (i *ImpressionListenerTest) LogImpression(data impressionlistener.ILObject)
 	ilTest["Version"] = data.SDKLanguageVersion
 	ilTest["InstanceName"] = data.InstanceID
 	ilTest["Pt"] = data.Impression.Pt
+	ilTest["Properties"] = data.Properties
 
 	ilResult[data.Impression.FeatureName] = ilTest
 }
@@ -414,6 +730,7 @@ func getClientForListener() SplitClient {
 		impressionListener: impresionL,
 		factory:            factory,
 		impressionManager:  impressionManager,
+		validator:          inputValidation{logger: logger},
 	}
 
 	factory.status.Store(sdkStatusReady)
@@ -450,23 +767,308 @@ func TestImpressionListenerForTreatments(t *testing.T) {
 	expectedTreatment(res["feature"], "TreatmentA", t)
 	expectedTreatment(res["feature2"], "TreatmentB", t)
 
-	if len(ilResult) != 2 {
-		t.Error("Error on ImpressionListener")
+	if len(ilResult) != 2 {
+		t.Error("Error on ImpressionListener")
+	}
+
+	expectedVersion := "go-" + splitio.Version
+
+	if !compareListener(ilResult["feature"].(map[string]interface{}), "feature", "user1", "aLabel", "TreatmentA", int64(123), "", "test", cfg.InstanceName, expectedVersion) {
+		t.Error("Impression should match")
+	}
+
+	if !compareListener(ilResult["feature2"].(map[string]interface{}), "feature2", "user1", "bLabel", "TreatmentB", int64(123), "", "test", cfg.InstanceName, expectedVersion) {
+		t.Error("Impression should match")
+	}
+	ilResult = make(map[string]interface{})
+
+	delete(ilResult, "feature")
+	delete(ilResult, "feature2")
+}
+
+func TestImpressionListenerWithProperties(t *testing.T) {
+	client := getClientForListener()
+
+	res := client.TreatmentWithOptions("user1", "feature", nil, &EvaluationOptions{
+		Properties: map[string]interface{}{"requestId": "abc-123", "page": "checkout", "invalid": []string{}},
+	})
+	expectedTreatment(res.Treatment, "TreatmentA", t)
+	properties, _ := ilResult["feature"].(map[string]interface{})["Properties"].(map[string]interface{})
+	if properties["requestId"] != "abc-123" || properties["page"] != "checkout" {
+		t.Error("Properties should reach the listener", properties)
+	}
+	if value, ok := properties["invalid"]; !ok || value != nil {
+		t.Error("Properties of invalid type should be set to nil")
+	}
+
+	results := client.TreatmentsWithOptions("user1", []string{"feature", "feature2"}, nil, &EvaluationOptions{
+		Properties: map[string]interface{}{"requestId": "abc-456"},
+	})
+	expectedTreatment(results["feature2"].Treatment, "TreatmentB", t)
+	for _, feature := range []string{"feature", "feature2"} {
+		properties, _ := ilResult[feature].(map[string]interface{})["Properties"].(map[string]interface{})
+		if properties["requestId"] != "abc-456" {
+			t.Error("Properties should be attached to every impression", feature)
+		}
+	}
+
+	props := make(map[string]interface{})
+	for i := 0; i < 33; i++ {
+		props[fmt.Sprintf("prop-%d", i)] = strings.Repeat("a", 1024)
+	}
+	res = client.TreatmentWithOptions("user1", "feature", nil, &EvaluationOptions{Properties: props})
+	expectedTreatment(res.Treatment, "TreatmentA", t)
+	if ilResult["feature"].(map[string]interface{})["Properties"].(map[string]interface{}) != nil {
+		t.Error("Properties exceeding the maximum size should be discarded")
+	}
+
+	client.Treatment("user1", "feature", nil)
+	if ilResult["feature"].(map[string]interface{})["Properties"].(map[string]interface{}) != nil {
+		t.Error("Impressions without options should have no properties")
+	}
+	ilResult = make(map[string]interface{})
+}
+
+type eventListenerMock struct {
+	events []dtos.EventDTO
+}
+
+func (l *eventListenerMock) LogEvent(data dtos.EventDTO) {
+	l.events = append(l.events, data)
+}
+
+type panickingEventListener struct{}
+
+func (l *panickingEventListener) LogEvent(data dtos.EventDTO) {
+	panic("listener error")
+}
+
+func TestImpressionsWithPropertiesAreDedupedByProperties(t *testing.T) {
+	client := getClientForListener()
+	logger := logging.NewLogger(nil)
+	counter := provisional.NewImpressionsCounter()
+	client.factory.cfg.ImpressionsMode = commonsCfg.ImpressionsModeOptimized
+	client.factory.impressionObserver = impressionproperties.NewObserver(500)
+	client.impressionManager, _ = provisional.NewImpressionManager(commonsCfg.ManagerConfig{
+		ImpressionsMode: commonsCfg.ImpressionsModeOptimized,
+		OperationMode:   conf.InMemoryStandAlone,
+		ListenerEnabled: true,
+	}, counter)
+	queue := impressionproperties.NewQueue(100, nil, logger)
+	client.impressions = queue
+
+	client.Treatment("user1", "feature", nil)
+	client.Treatment("user1", "feature", nil)
+	if logged := queue.PopN(10); len(logged) != 1 || logged[0].Properties != "" {
+		t.Error("Impressions without properties should be deduped", logged)
+	}
+
+	options := &EvaluationOptions{Properties: map[string]interface{}{"requestId": "abc"}}
+	client.TreatmentWithOptions("user1", "feature", nil, options)
+	client.TreatmentWithOptions("user1", "feature", nil, options)
+	queued := queue.PopN(10)
+	if len(queued) != 1 || queued[0].Properties != `{"requestId":"abc"}` {
+		t.Error("Impressions with the same properties should be deduped, keeping their properties", queued)
+	}
+
+	client.TreatmentWithOptions("user1", "feature", nil, &EvaluationOptions{Properties: map[string]interface{}{"requestId": "def"}})
+	queued = queue.PopN(10)
+	if len(queued) != 1 || queued[0].Properties != `{"requestId":"def"}` {
+		t.Error("Impressions with different properties should not be deduped", queued)
+	}
+
+	total := int64(0)
+	for _, count := range counter.PopAll() {
+		total += count
+	}
+	if total != 5 {
+		t.Error("Every impression should be counted. Actual:", total)
+	}
+}
+
+func TestImpressionPropertiesInMemory(t *testing.T) {
+	var splitsMock, _ = ioutil.ReadFile("../../testdata/splits_mock_2.json")
+	posted := make(chan []impressionproperties.ImpressionsDTO, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/splitChanges":
+			fmt.Fprintln(w, string(splitsMock))
+		case "/testImpressions/bulk":
+			var bulk []impressionproperties.ImpressionsDTO
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &bulk)
+			if len(bulk) > 0 {
+				select {
+				case posted <- bulk:
+				default:
+				}
+			}
+			fmt.Fprintln(w, "ok")
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	}))
+	defer ts.Close()
+
+	cfg := conf.Default()
+	cfg.Advanced.EventsURL = ts.URL
+	cfg.Advanced.SdkURL = ts.URL
+	cfg.Advanced.StreamingEnabled = false
+	factory, _ := NewSplitFactory("test", cfg)
+	client := factory.Client()
+	client.BlockUntilReady(2)
+
+	client.TreatmentWithOptions("user1", "DEMO_MURMUR2", nil, &EvaluationOptions{Properties: map[string]interface{}{"requestId": "abc"}})
+	client.Destroy()
+
+	select {
+	case bulk := <-posted:
+		if len(bulk) != 1 || bulk[0].TestName != "DEMO_MURMUR2" || bulk[0].KeyImpressions[0].Properties != `{"requestId":"abc"}` {
+			t.Error("Properties should be posted along with the impression", bulk)
+		}
+	case <-time.After(4 * time.Second):
+		t.Error("Impressions should be posted on destroy")
+	}
+}
+
+func TestImpressionPropertiesRedis(t *testing.T) {
+	prefixedClient, _ := redis.NewRedisClient(&commonsCfg.RedisConfig{
+		Host:     "localhost",
+		Port:     6379,
+		Database: 1,
+		Password: "",
+		Prefix:   "testPrefix",
+	}, logging.NewLogger(&logging.LoggerOptions{}))
+	raw, _ := json.Marshal(*valid)
+	prefixedClient.Set("SPLITIO.split.valid", raw, 0)
+	prefixedClient.Set("SPLITIO.splits.till", 1494593336752, 0)
+	prefixedClient.Del("SPLITIO.impressions")
+	defer deleteDataGenerated(prefixedClient)
+
+	cfg := conf.Default()
+	cfg.ImpressionsMode = commonsCfg.ImpressionsModeDebug
+	cfg.OperationMode = conf.RedisConsumer
+	cfg.Redis = commonsCfg.RedisConfig{
+		Host:     "localhost",
+		Port:     6379,
+		Database: 1,
+		Password: "",
+		Prefix:   "testPrefix",
+	}
+	factory, _ := NewSplitFactory("apikey", cfg)
+	client := factory.Client()
+	client.TreatmentWithOptions("user1", "valid", nil, &EvaluationOptions{Properties: map[string]interface{}{"requestId": "abc"}})
+	client.Treatment("user1", "valid", nil)
+
+	stored, _ := prefixedClient.LRange("SPLITIO.impressions", 0, -1)
+	if len(stored) != 2 {
+		t.Error("Every impression should be written to the impressions list. Actual:", len(stored))
+		return
+	}
+	var first, second map[string]map[string]interface{}
+	json.Unmarshal([]byte(stored[0]), &first)
+	json.Unmarshal([]byte(stored[1]), &second)
+	if first["i"]["properties"] != `{"requestId":"abc"}` || first["i"]["f"] != "valid" {
+		t.Error("Properties should be stored along with the impression", first)
+	}
+	if _, ok := second["i"]["properties"]; ok {
+		t.Error("Impressions without properties should be stored as before", second)
+	}
+}
+
+func TestImpressionPropertiesLocalhost(t *testing.T) {
+	dir, err := ioutil.TempDir("", "properties")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/splits_with_rules.yaml"
+	sdkConf.ImpressionsMode = "debug"
+	sdkConf.RecordFile = filepath.Join(dir, "recorded.jsonl")
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+
+	client.TreatmentWithOptions("qa_user", "checkout_flow", nil, &EvaluationOptions{Properties: map[string]interface{}{"requestId": "abc"}})
+	client.Destroy()
+
+	impressions := factory.Recorder().RecordedImpressions()
+	if len(impressions) != 1 || impressions[0].Properties != `{"requestId":"abc"}` {
+		t.Error("Properties should be recorded along with the impression. Actual:", impressions)
+	}
+
+	data, _ := ioutil.ReadFile(sdkConf.RecordFile)
+	var record filesink.Record
+	json.Unmarshal(bytes.TrimSpace(data), &record)
+	if record.Impression == nil || record.Impression.FeatureName != "checkout_flow" || record.Properties["requestId"] != "abc" {
+		t.Error("Properties should be written to the record file. Actual:", string(data))
+	}
+}
+
+func TestDestroyClosesFileSinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	sink, _ := filesink.NewFileSink(filesink.Options{Directory: dir, Compress: true}, nil)
+
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/splits_with_rules.yaml"
+	sdkConf.Advanced.ImpressionListener = sink
+	sdkConf.Advanced.EventListener = sink
+	factory, err := NewSplitFactory(conf.Localhost, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	client.BlockUntilReady(1)
+	client.Treatment("qa_user", "checkout_flow", nil)
+	client.Track("qa_user", "user", "checkout", nil, nil)
+	client.Destroy()
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(names) != 1 || !strings.HasSuffix(names[0], ".jsonl.gz") {
+		t.Error("The file sink should be closed, compressing its file. Actual:", names)
 	}
+}
 
-	expectedVersion := "go-" + splitio.Version
+func TestEventListener(t *testing.T) {
+	client := getClient()
+	listener := &eventListenerMock{}
+	client.eventListener = listener
 
-	if !compareListener(ilResult["feature"].(map[string]interface{}), "feature", "user1", "aLabel", "TreatmentA", int64(123), "", "test", cfg.InstanceName, expectedVersion) {
-		t.Error("Impression should match")
+	err := client.Track("key", "trafictype", "eventType", 1.5, map[string]interface{}{"one": "test"})
+	if err != nil {
+		t.Error("Track should not fail", err)
+	}
+	if len(listener.events) != 1 {
+		t.Error("Tracked event should be handed to the listener")
+		return
+	}
+	event := listener.events[0]
+	if event.Key != "key" || event.TrafficTypeName != "trafictype" || event.EventTypeID != "eventType" || event.Value != 1.5 || event.Properties["one"] != "test" {
+		t.Error("Unexpected event", event)
 	}
 
-	if !compareListener(ilResult["feature2"].(map[string]interface{}), "feature2", "user1", "bLabel", "TreatmentB", int64(123), "", "test", cfg.InstanceName, expectedVersion) {
-		t.Error("Impression should match")
+	client.Track("key", "trafictype", "event type with spaces", nil, nil)
+	if len(listener.events) != 1 {
+		t.Error("Invalid events should not be handed to the listener")
 	}
-	ilResult = make(map[string]interface{})
 
-	delete(ilResult, "feature")
-	delete(ilResult, "feature2")
+	client.eventListener = &panickingEventListener{}
+	if client.Track("key", "trafictype", "eventType", nil, nil) != nil {
+		t.Error("A panicking event listener should not affect Track")
+	}
 }
 
 // TEST BLOCK UNTIL READY //
@@ -500,6 +1102,43 @@ func TestBlockUntilReadyWrongTimerPassed(t *testing.T) {
 	}
 }
 
+func TestBlockUntilReadyTimeout(t *testing.T) {
+	cfg := conf.Default()
+	cfg.BlockUntilReadyTimeout = 50 * time.Millisecond
+	factory := &SplitFactory{cfg: cfg, readinessSubscriptors: make(map[int]chan int)}
+	factory.status.Store(sdkStatusInitializing)
+	client := &SplitClient{factory: factory}
+	manager := &SplitManager{factory: factory}
+
+	err := client.BlockUntilReadyTimeout(0)
+	if err == nil || err.Error() != "SDK Initialization: timer must be positive number" {
+		t.Error("Unexpected error", err)
+	}
+
+	err = client.BlockUntilReadyTimeout(50 * time.Millisecond)
+	if err == nil || err.Error() != "SDK Initialization: time of 50ms exceeded" {
+		t.Error("Unexpected error", err)
+	}
+
+	err = factory.WaitUntilReady()
+	if err == nil || err.Error() != "SDK Initialization: time of 50ms exceeded" {
+		t.Error("The configured timeout should be used. Actual:", err)
+	}
+
+	err = manager.BlockUntilReady(1)
+	if err == nil || err.Error() != "SDK Initialization: time of 1 exceeded" {
+		t.Error("Unexpected error", err)
+	}
+
+	factory.broadcastReadiness(sdkStatusReady)
+	if err = manager.BlockUntilReadyTimeout(time.Second); err != nil {
+		t.Error("Error was not expected", err)
+	}
+	if err = client.BlockUntilReadyTimeout(0); err != nil {
+		t.Error("A ready factory should not check the timeout", err)
+	}
+}
+
 func TestBlockUntilReadyStatusLocalhost(t *testing.T) {
 	file, err := ioutil.TempFile("", "splitio_tests")
 	if err != nil {
@@ -1638,3 +2277,423 @@ func TestClientDebug(t *testing.T) {
 		return
 	}
 }
+
+func TestClientNone(t *testing.T) {
+	var isDestroyCalled int64
+	var splitsMock, _ = ioutil.ReadFile("../../testdata/splits_mock_2.json")
+
+	countChannel := make(chan string, 1)
+	keysChannel := make(chan string, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/splitChanges":
+			fmt.Fprintln(w, string(splitsMock))
+			return
+		case "/testImpressions/bulk":
+			t.Error("It should not send impressions in none mode")
+			fmt.Fprintln(w, "ok")
+		case "/testImpressions/count":
+			fmt.Fprintln(w, "ok")
+			if atomic.LoadInt64(&isDestroyCalled) == 1 {
+				rBody, _ := ioutil.ReadAll(r.Body)
+
+				var dataInPost map[string][]map[string]interface{}
+				err := json.Unmarshal(rBody, &dataInPost)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				for _, v := range dataInPost["pf"] {
+					if v["f"] == "DEMO_MURMUR2" && v["rc"].(float64) != 3 {
+						t.Error("Wrong rc")
+					}
+				}
+				countChannel <- "finished"
+			}
+		case "/keys/ss":
+			fmt.Fprintln(w, "ok")
+			rBody, _ := ioutil.ReadAll(r.Body)
+
+			var dataInPost uniquekeys.BulkDTO
+			err := json.Unmarshal(rBody, &dataInPost)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(dataInPost.Keys) != 2 {
+				t.Error("It should send unique keys for two features")
+			}
+			for _, v := range dataInPost.Keys {
+				if v.Feature == "DEMO_MURMUR2" && len(v.Keys) != 2 {
+					t.Error("It should send each key once")
+				}
+			}
+			keysChannel <- "finished"
+		case "/events/bulk":
+			fmt.Fprintln(w, "ok")
+		case "/segmentChanges":
+			fallthrough
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	}))
+	defer ts.Close()
+
+	impTest := &ImpressionListenerTest{}
+	cfg := conf.Default()
+	cfg.LabelsEnabled = true
+	cfg.Advanced.EventsURL = ts.URL
+	cfg.Advanced.SdkURL = ts.URL
+	cfg.Advanced.ImpressionListener = impTest
+	cfg.ImpressionsMode = conf.ImpressionsModeNone
+
+	factory, _ := NewSplitFactory("test", cfg)
+	client := factory.Client()
+	client.BlockUntilReady(2)
+
+	time.Sleep(300 * time.Millisecond) // Let's wait until first call of recorders have finished
+	client.Treatment("user1", "DEMO_MURMUR2", nil)
+	client.Treatment("user2", "DEMO_MURMUR2", nil)
+	client.Treatments("user1", []string{"DEMO_MURMUR2", "DEMO_MURMUR"}, nil)
+	if _, ok := ilResult["DEMO_MURMUR"]; !ok {
+		t.Error("Impressions should still reach the listener")
+	}
+
+	atomic.AddInt64(&isDestroyCalled, 1)
+	client.Destroy()
+
+	for _, channel := range []chan string{countChannel, keysChannel} {
+		select {
+		case <-channel:
+		case <-time.After(4 * time.Second):
+			t.Error("The test couldn't send impression counts and unique keys")
+			return
+		}
+	}
+}
+
+func TestClientNoneTrackerFullBeforeReady(t *testing.T) {
+	var splitsMock, _ = ioutil.ReadFile("../../testdata/splits_mock_2.json")
+
+	release := make(chan struct{})
+	keysChannel := make(chan uniquekeys.BulkDTO, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/splitChanges":
+			<-release
+			fmt.Fprintln(w, string(splitsMock))
+		case "/keys/ss":
+			fmt.Fprintln(w, "ok")
+			rBody, _ := ioutil.ReadAll(r.Body)
+			var dataInPost uniquekeys.BulkDTO
+			if err := json.Unmarshal(rBody, &dataInPost); err != nil {
+				t.Error(err)
+				return
+			}
+			select {
+			case keysChannel <- dataInPost:
+			default:
+			}
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	}))
+	defer ts.Close()
+
+	cfg := conf.Default()
+	cfg.Advanced.EventsURL = ts.URL
+	cfg.Advanced.SdkURL = ts.URL
+	cfg.Advanced.UniqueKeysCacheSize = 1
+	cfg.ImpressionsMode = conf.ImpressionsModeNone
+
+	factory, _ := NewSplitFactory("test", cfg)
+	client := factory.Client()
+
+	done := make(chan struct{})
+	go func() {
+		client.Treatment("user1", "DEMO_MURMUR2", nil)
+		client.Treatment("user2", "DEMO_MURMUR2", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Filling the unique keys tracker before the SDK is ready should not block")
+	}
+
+	close(release)
+	if err := client.BlockUntilReady(2); err != nil {
+		t.Error("The SDK should be ready", err)
+	}
+	client.Destroy()
+
+	select {
+	case bulk := <-keysChannel:
+		if len(bulk.Keys) != 1 || bulk.Keys[0].Feature != "DEMO_MURMUR2" || len(bulk.Keys[0].Keys) != 1 || bulk.Keys[0].Keys[0] != "user1" {
+			t.Error("Keys tracked before the SDK was ready should be submitted", bulk)
+		}
+	case <-time.After(4 * time.Second):
+		t.Error("Unique keys should be submitted on destroy")
+	}
+}
+
+type impressionRecorderMock struct {
+	FlushImpressionsCall func(bulkSize int64) error
+}
+
+func (r *impressionRecorderMock) SynchronizeImpressions(bulkSize int64) error { return nil }
+func (r *impressionRecorderMock) FlushImpressions(bulkSize int64) error {
+	return r.FlushImpressionsCall(bulkSize)
+}
+
+type eventRecorderMock struct {
+	FlushEventsCall func(bulkSize int64) error
+}
+
+func (r *eventRecorderMock) SynchronizeEvents(bulkSize int64) error { return nil }
+func (r *eventRecorderMock) FlushEvents(bulkSize int64) error       { return r.FlushEventsCall(bulkSize) }
+
+type telemetryRecorderMock struct {
+	SynchronizeTelemetryCall func() error
+}
+
+func (r *telemetryRecorderMock) SynchronizeTelemetry() error { return r.SynchronizeTelemetryCall() }
+
+func TestFactoryFlush(t *testing.T) {
+	var impressionsBulk, eventsBulk int64
+	telemetryCalls := 0
+	cfg := conf.Default()
+	factory := &SplitFactory{
+		cfg: cfg,
+		workers: &synchronizer.Workers{
+			ImpressionRecorder: &impressionRecorderMock{FlushImpressionsCall: func(bulkSize int64) error {
+				impressionsBulk = bulkSize
+				return nil
+			}},
+			EventRecorder: &eventRecorderMock{FlushEventsCall: func(bulkSize int64) error {
+				eventsBulk = bulkSize
+				return errors.New("events error")
+			}},
+			TelemetryRecorder: &telemetryRecorderMock{SynchronizeTelemetryCall: func() error {
+				telemetryCalls++
+				return errors.New("telemetry error")
+			}},
+		},
+	}
+	factory.status.Store(sdkStatusReady)
+
+	err := factory.Flush(context.Background())
+	flushErr, ok := err.(*MultiError)
+	if !ok || len(flushErr.Errors) != 2 {
+		t.Error("Errors should be aggregated", err)
+		return
+	}
+	if err.Error() != "Flush: events: events error; telemetry: telemetry error" {
+		t.Error("Unexpected message", err.Error())
+	}
+	if impressionsBulk != cfg.Advanced.ImpressionsBulkSize || eventsBulk != cfg.Advanced.EventsBulkSize || telemetryCalls != 1 {
+		t.Error("Every recorder should be flushed with the configured bulk sizes")
+	}
+
+	blocked := make(chan struct{})
+	released := make(chan struct{})
+	var eventsFlushed int64
+	factory.workers.ImpressionRecorder = &impressionRecorderMock{FlushImpressionsCall: func(bulkSize int64) error {
+		<-blocked
+		close(released)
+		return nil
+	}}
+	factory.workers.EventRecorder = &eventRecorderMock{FlushEventsCall: func(bulkSize int64) error {
+		atomic.AddInt64(&eventsFlushed, 1)
+		return nil
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if factory.Flush(ctx) != context.DeadlineExceeded {
+		t.Error("Flush should return when the context is done")
+	}
+	close(blocked)
+	<-released
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt64(&eventsFlushed) != 0 {
+		t.Error("Flush should stop submitting once the context is done")
+	}
+
+	localhost := &SplitFactory{cfg: conf.Default()}
+	localhost.cfg.OperationMode = conf.Localhost
+	localhost.status.Store(sdkStatusReady)
+	if localhost.Flush(context.Background()) != nil {
+		t.Error("Flush should be a no-op in localhost mode")
+	}
+
+	localhost.status.Store(sdkStatusDestroyed)
+	if localhost.Flush(context.Background()) == nil {
+		t.Error("Flush should fail once destroyed")
+	}
+}
+
+type batchListenerTest struct {
+	mutex       sync.Mutex
+	impressions int
+}
+
+func (l *batchListenerTest) LogImpression(data impressionlistener.ILObject) {
+	l.LogImpressions([]impressionlistener.ILObject{data})
+}
+
+func (l *batchListenerTest) LogImpressions(data []impressionlistener.ILObject) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.impressions += len(data)
+}
+
+func TestFactoryCloseLeavesNoGoroutines(t *testing.T) {
+	file, err := ioutil.TempFile("", "splitio_tests")
+	if err != nil {
+		t.Error("Couldn't create temporary file for localhost client tests: ", err)
+		return
+	}
+	defer os.Remove(file.Name())
+	file.Write([]byte("feature1 on\n"))
+	file.Close()
+
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = file.Name()
+	assertCloseLeavesNoGoroutines(t, conf.Localhost, sdkConf, "feature1", nil)
+
+	var splitsMock, _ = ioutil.ReadFile("../../testdata/splits_mock_2.json")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/splitChanges":
+			fmt.Fprintln(w, string(splitsMock))
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	}))
+	defer ts.Close()
+
+	sdkConf = conf.Default()
+	sdkConf.Advanced.SdkURL = ts.URL
+	sdkConf.Advanced.EventsURL = ts.URL
+	sdkConf.Advanced.StreamingEnabled = false
+	assertCloseLeavesNoGoroutines(t, "apikey", sdkConf, "DEMO_MURMUR2", ts)
+}
+
+// assertCloseLeavesNoGoroutines creates a factory with an async batch impression listener, generates impressions
+// and checks that closing it delivers them all and stops every goroutine it started
+func assertCloseLeavesNoGoroutines(t *testing.T, apikey string, sdkConf *conf.SplitSdkConfig, feature string, ts *httptest.Server) {
+	before := runtime.NumGoroutine()
+
+	listener := &batchListenerTest{}
+	sdkConf.Advanced.ImpressionListeners = []impressionlistener.FilteredListener{{Listener: listener}}
+	sdkConf.Advanced.ImpressionListenerQueueSize = 100
+	sdkConf.Advanced.ImpressionListenerWorkers = 3
+	factory, err := NewSplitFactory(apikey, sdkConf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client := factory.Client()
+	if err := client.BlockUntilReady(2); err != nil {
+		t.Error(apikey, err)
+	}
+	for i := 0; i < 10; i++ {
+		client.Treatment("key", feature, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := factory.Close(ctx); err != nil {
+		t.Error("Close should not fail", apikey, err)
+	}
+
+	listener.mutex.Lock()
+	if listener.impressions != 10 {
+		t.Error("Impression listeners should be drained on close. Delivered:", listener.impressions)
+	}
+	listener.mutex.Unlock()
+
+	if !factory.IsDestroyed() || factory.Close(ctx) != nil {
+		t.Error("Closing a destroyed factory should do nothing")
+	}
+
+	if ts != nil {
+		// Idle keep-alive connections hold goroutines in both ends
+		ts.CloseClientConnections()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Error("Goroutines were leaked after closing the factory.", apikey, "Before:", before, "After:", after)
+	}
+}
+
+type blockingEventListener struct {
+	released chan struct{}
+}
+
+func (l *blockingEventListener) LogEvent(data dtos.EventDTO) {}
+
+func (l *blockingEventListener) Close() error {
+	<-l.released
+	return nil
+}
+
+func TestFactoryDestroyIsBounded(t *testing.T) {
+	previous := destroyTimeout
+	destroyTimeout = 50 * time.Millisecond
+	defer func() { destroyTimeout = previous }()
+
+	listener := &blockingEventListener{released: make(chan struct{})}
+	defer close(listener.released)
+	cfg := conf.Default()
+	cfg.Advanced.EventListener = listener
+	factory := &SplitFactory{cfg: cfg, logger: logging.NewLogger(nil)}
+	factory.status.Store(sdkStatusReady)
+
+	done := make(chan struct{})
+	go func() {
+		factory.Destroy()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Destroy should return once its timeout elapses")
+	}
+}
+
+func TestFactoryConcurrentClose(t *testing.T) {
+	released := make(chan struct{})
+	factory := &SplitFactory{
+		cfg: conf.Default(),
+		workers: &synchronizer.Workers{
+			ImpressionRecorder: &impressionRecorderMock{FlushImpressionsCall: func(bulkSize int64) error {
+				<-released
+				return errors.New("impressions error")
+			}},
+		},
+		logger: logging.NewLogger(nil),
+	}
+	factory.status.Store(sdkStatusReady)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if factory.Close(ctx) != context.DeadlineExceeded {
+		t.Error("Close should return when the context is done")
+	}
+
+	results := make(chan error, 2)
+	go func() { results <- factory.Close(context.Background()) }()
+	go func() { results <- factory.Close(context.Background()) }()
+	close(released)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err == nil || err.Error() != "Close: impressions: impressions error" {
+			t.Error("Every Close should wait for the flush and report its errors", err)
+		}
+	}
+}
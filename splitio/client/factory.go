@@ -3,8 +3,11 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,24 +18,29 @@ import (
 	"github.com/splitio/go-client/splitio/engine"
 	"github.com/splitio/go-client/splitio/engine/evaluator"
 	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	impressionproperties "github.com/splitio/go-client/splitio/impressionProperties"
+	"github.com/splitio/go-client/splitio/localhost"
+	"github.com/splitio/go-client/splitio/overrides"
+	uniquekeys "github.com/splitio/go-client/splitio/uniqueKeys"
 	config "github.com/splitio/go-split-commons/conf"
 	"github.com/splitio/go-split-commons/dtos"
 	"github.com/splitio/go-split-commons/provisional"
 	"github.com/splitio/go-split-commons/service"
-	"github.com/splitio/go-split-commons/service/local"
+	"github.com/splitio/go-split-commons/service/api"
 	"github.com/splitio/go-split-commons/storage"
 	"github.com/splitio/go-split-commons/storage/mutexmap"
 	"github.com/splitio/go-split-commons/storage/mutexqueue"
 	"github.com/splitio/go-split-commons/storage/redis"
 	"github.com/splitio/go-split-commons/synchronizer"
 	"github.com/splitio/go-split-commons/synchronizer/worker/event"
-	"github.com/splitio/go-split-commons/synchronizer/worker/impression"
 	"github.com/splitio/go-split-commons/synchronizer/worker/impressionscount"
 	"github.com/splitio/go-split-commons/synchronizer/worker/metric"
 	"github.com/splitio/go-split-commons/synchronizer/worker/segment"
 	"github.com/splitio/go-split-commons/synchronizer/worker/split"
 	"github.com/splitio/go-split-commons/tasks"
+	"github.com/splitio/go-toolkit/asynctask"
 	"github.com/splitio/go-toolkit/logging"
+	predis "github.com/splitio/go-toolkit/redis"
 )
 
 const (
@@ -43,6 +51,17 @@ const (
 	sdkInitializationFailed = -1
 )
 
+// localhostReloadPeriod is how often localhost files are checked for modifications, unless
+// TaskPeriods.SplitSyncPeriod is shorter
+const localhostReloadPeriod = time.Second
+
+// destroyTimeout is how long Destroy waits for the factory to shut down. It's a variable so tests can shorten it
+var destroyTimeout = 30 * time.Second
+
+// impressionObserverSize is how many impressions with properties are remembered to dedupe them in optimized mode,
+// matching the impression observer of go-split-commons
+const impressionObserverSize = 500
+
 type sdkStorages struct {
 	splits      storage.SplitStorageConsumer
 	segments    storage.SegmentStorageConsumer
@@ -65,13 +84,43 @@ type SplitFactory struct {
 	logger                logging.LoggerInterface
 	syncManager           *synchronizer.Manager
 	impressionManager     provisional.ImpressionManager
+	uniqueKeysTask        *asynctask.AsyncTask
+	uniqueKeysRecorder    *uniquekeys.Recorder
+	impressionObserver    *impressionproperties.Observer
+	workers               *synchronizer.Workers
+	redisClient           *predis.PrefixedRedisClient
+	localhostReloader     *localhost.Reloader
+	localhostSync         synchronizer.Synchronizer
+	overrides             *overrides.Store
+	localhostRecorder     *localhost.Recorder
+	shutdownOnce          sync.Once
+	shutdownDone          chan struct{}
+	shutdownErrs          []error
+}
+
+// MultiError aggregates the errors found while running an operation that involves several components,
+// such as Flush or Close
+type MultiError struct {
+	Operation string
+	Errors    []error
+}
+
+// Error returns the messages of every aggregated error
+func (e *MultiError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return e.Operation + ": " + strings.Join(messages, "; ")
 }
 
 // Client returns the split client instantiated by the factory
 func (f *SplitFactory) Client() *SplitClient {
+	splitEvaluator := evaluator.NewEvaluator(f.storages.splits, f.storages.segments, engine.NewEngine(f.logger), f.logger)
+	splitEvaluator.SetOverrides(f.Overrides())
 	return &SplitClient{
 		logger:      f.logger,
-		evaluator:   evaluator.NewEvaluator(f.storages.splits, f.storages.segments, engine.NewEngine(f.logger), f.logger),
+		evaluator:   splitEvaluator,
 		impressions: f.storages.impressions,
 		metrics:     f.storages.telemetry,
 		events:      f.storages.events,
@@ -81,6 +130,7 @@ func (f *SplitFactory) Client() *SplitClient {
 		},
 		factory:            f,
 		impressionListener: f.impressionListener,
+		eventListener:      f.cfg.Advanced.EventListener,
 		impressionManager:  f.impressionManager,
 	}
 }
@@ -88,11 +138,24 @@ func (f *SplitFactory) Client() *SplitClient {
 // Manager returns the split manager instantiated by the factory
 func (f *SplitFactory) Manager() *SplitManager {
 	return &SplitManager{
-		splitStorage: f.storages.splits,
-		validator:    inputValidation{logger: f.logger},
-		logger:       f.logger,
-		factory:      f,
+		splitStorage:   f.storages.splits,
+		segmentStorage: f.storages.segments,
+		overrides:      f.Overrides(),
+		validator:      inputValidation{logger: f.logger},
+		logger:         f.logger,
+		factory:        f,
+	}
+}
+
+// Overrides returns the treatments forced at runtime for the clients of the factory. They take precedence over the
+// split definitions in every operation mode and produce impressions labeled "override"
+func (f *SplitFactory) Overrides() *overrides.Store {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.overrides == nil {
+		f.overrides = overrides.NewStore()
 	}
+	return f.overrides
 }
 
 // IsDestroyed returns true if tbe client has been destroyed
@@ -105,11 +168,25 @@ func (f *SplitFactory) IsReady() bool {
 	return f.status.Load() == sdkStatusReady
 }
 
+// DroppedListenerImpressions returns how many impressions were discarded before reaching the impression listener
+func (f *SplitFactory) DroppedListenerImpressions() int64 {
+	if f.impressionListener == nil {
+		return 0
+	}
+	return f.impressionListener.Dropped()
+}
+
 // initializates task for localhost mode
 func (f *SplitFactory) initializationLocalhost(readyChannel chan int) {
 	f.syncManager.Start()
 
 	<-readyChannel
+	if f.localhostReloader != nil {
+		// The reloader takes over the periodic synchronization of splits, so that it's the only one calling
+		// SynchronizeSplits once the SDK is ready
+		f.localhostSync.StopPeriodicFetching()
+		f.localhostReloader.Start()
+	}
 	f.broadcastReadiness(sdkStatusReady)
 }
 
@@ -119,6 +196,9 @@ func (f *SplitFactory) initializationInMemory(readyChannel chan int) {
 	msg := <-readyChannel
 	switch msg {
 	case synchronizer.Ready:
+		if f.uniqueKeysTask != nil {
+			f.uniqueKeysTask.Start()
+		}
 		// Broadcast ready status for SDK
 		f.broadcastReadiness(sdkStatusReady)
 	default:
@@ -155,16 +235,43 @@ func (f *SplitFactory) unsubscribe(name int, subscriptor chan int) {
 	}
 }
 
-// BlockUntilReady blocks client or manager until the SDK is ready, error occurs or times out
+// BlockUntilReady blocks client or manager until the SDK is ready, error occurs or times out after timer seconds
 func (f *SplitFactory) BlockUntilReady(timer int) error {
+	timedOut, err := f.blockUntilReady(time.Second * time.Duration(timer))
+	if timedOut {
+		return fmt.Errorf("SDK Initialization: time of %d exceeded", timer)
+	}
+	return err
+}
+
+// BlockUntilReadyTimeout blocks client or manager until the SDK is ready, error occurs or timeout elapses
+func (f *SplitFactory) BlockUntilReadyTimeout(timeout time.Duration) error {
+	timedOut, err := f.blockUntilReady(timeout)
+	if timedOut {
+		return fmt.Errorf("SDK Initialization: time of %s exceeded", timeout)
+	}
+	return err
+}
+
+// WaitUntilReady blocks client or manager until the SDK is ready, error occurs or the timeout set in the config
+// (BlockUntilReadyTimeout, or BlockUntilReady in seconds) elapses
+func (f *SplitFactory) WaitUntilReady() error {
+	if f.cfg.BlockUntilReadyTimeout > 0 {
+		return f.BlockUntilReadyTimeout(f.cfg.BlockUntilReadyTimeout)
+	}
+	return f.BlockUntilReady(f.cfg.BlockUntilReady)
+}
+
+// blockUntilReady waits for the readiness of the SDK, reporting whether the timeout elapsed first
+func (f *SplitFactory) blockUntilReady(timeout time.Duration) (bool, error) {
 	if f.IsReady() {
-		return nil
+		return false, nil
 	}
-	if timer <= 0 {
-		return errors.New("SDK Initialization: timer must be positive number")
+	if timeout <= 0 {
+		return false, errors.New("SDK Initialization: timer must be positive number")
 	}
 	if f.IsDestroyed() {
-		return errors.New("SDK Initialization: Client is destroyed")
+		return false, errors.New("SDK Initialization: Client is destroyed")
 	}
 	block := make(chan int, 1)
 
@@ -188,27 +295,282 @@ func (f *SplitFactory) BlockUntilReady(timer int) error {
 		case sdkStatusReady:
 			break
 		case sdkInitializationFailed:
-			return errors.New("SDK Initialization failed")
+			return false, errors.New("SDK Initialization failed")
 		}
-	case <-time.After(time.Second * time.Duration(timer)):
-		return fmt.Errorf("SDK Initialization: time of %d exceeded", timer)
+	case <-time.After(timeout):
+		return true, nil
 	}
 
-	return nil
+	return false, nil
 }
 
-// Destroy stops all async tasks and clears all storages
+// Destroy stops all async tasks and clears all storages. It waits up to destroyTimeout for the shutdown, which
+// includes delivering the impressions queued for impression listeners, and then lets it finish in background
 func (f *SplitFactory) Destroy() {
-	if !f.IsDestroyed() {
-		removeInstanceFromTracker(f.apikey)
+	ctx, cancel := context.WithTimeout(context.Background(), destroyTimeout)
+	defer cancel()
+	if f.waitShutdown(ctx, f.startShutdown(false)) == ctx.Err() {
+		f.logger.Warning(fmt.Sprintf("Destroy: shutdown didn't finish within %s, it will keep running in background", destroyTimeout))
+	}
+}
+
+// Close gracefully destroys the factory. It stops the synchronization tasks, flushes the queued data, drains the
+// impression listeners and closes the redis client, blocking until done or until the context is done.
+// Returns a MultiError listing every step that failed. If the factory is already being destroyed, Close waits
+// for it and reports the result. When ctx is done first, Close returns the context error while the shutdown
+// keeps running in background
+func (f *SplitFactory) Close(ctx context.Context) error {
+	return f.waitShutdown(ctx, f.startShutdown(true))
+}
+
+// waitShutdown blocks until the shutdown is done, returning its errors, or until ctx is done, returning the
+// context error
+func (f *SplitFactory) waitShutdown(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		if len(f.shutdownErrs) > 0 {
+			return &MultiError{Operation: "Close", Errors: f.shutdownErrs}
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// startShutdown runs the shutdown of the factory the first time it's called, and returns a channel closed once
+// that shutdown is done
+func (f *SplitFactory) startShutdown(graceful bool) <-chan struct{} {
+	f.shutdownOnce.Do(func() {
+		f.shutdownDone = make(chan struct{})
+		go func() {
+			defer close(f.shutdownDone)
+			f.shutdownErrs = f.shutdown(graceful)
+		}()
+	})
+	return f.shutdownDone
+}
+
+// shutdown stops every background task. When graceful, queued data is flushed and the redis client closed
+func (f *SplitFactory) shutdown(graceful bool) []error {
+	removeInstanceFromTracker(f.apikey)
 	f.status.Store(sdkStatusDestroyed)
 
-	if f.cfg.OperationMode == conf.RedisConsumer {
-		return
+	errs := make([]error, 0)
+
+	if f.uniqueKeysTask != nil {
+		f.uniqueKeysTask.Stop(true)
+	}
+
+	if f.syncManager != nil && f.cfg.OperationMode != conf.RedisConsumer {
+		f.syncManager.Stop()
+	}
+
+	if f.localhostReloader != nil {
+		f.localhostReloader.Stop()
+	}
+
+	if notifier, ok := f.storages.splits.(*notifyingSplitStorage); ok {
+		notifier.close()
+	}
+
+	if f.localhostRecorder != nil {
+		if err := f.localhostRecorder.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("localhost recorder: %s", err.Error()))
+		}
+	}
+
+	if graceful {
+		if err := f.flush(context.Background()); err != nil {
+			errs = append(errs, err.(*MultiError).Errors...)
+		}
+	}
+
+	if f.impressionListener != nil {
+		f.impressionListener.Stop()
+	}
+	errs = append(errs, f.closeListeners()...)
+
+	if graceful && f.redisClient != nil {
+		if err := f.redisClient.Client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("redis: %s", err.Error()))
+		}
+	}
+	return errs
+}
+
+// closeListeners closes every configured listener that supports it, such as file sinks, so that their data reaches
+// stable storage. Listeners that can only be synced are synced instead
+func (f *SplitFactory) closeListeners() []error {
+	errs := make([]error, 0)
+	listeners := make([]interface{}, 0, len(f.cfg.Advanced.ImpressionListeners)+2)
+	listeners = append(listeners, f.cfg.Advanced.ImpressionListener, f.cfg.Advanced.EventListener)
+	for _, filtered := range f.cfg.Advanced.ImpressionListeners {
+		listeners = append(listeners, filtered.Listener)
+	}
+
+	closed := make(map[interface{}]bool)
+	for _, listener := range listeners {
+		if listener == nil {
+			continue
+		}
+		// The same listener may be set several times, ie: a file sink keeping both impressions and events
+		if reflect.TypeOf(listener).Comparable() {
+			if closed[listener] {
+				continue
+			}
+			closed[listener] = true
+		}
+
+		var err error
+		switch typed := listener.(type) {
+		case interface{ Close() error }:
+			err = typed.Close()
+		case interface{ Sync() error }:
+			err = typed.Sync()
+		default:
+			continue
+		}
+		if err != nil {
+			f.logger.Error("Error closing listener on destroy", err.Error())
+			errs = append(errs, fmt.Errorf("listener: %s", err.Error()))
+		}
+	}
+	return errs
+}
+
+// LocalhostUpdates returns a channel receiving an event with the names of the splits and segments changed every
+// time the localhost files are modified and reloaded, and a function to unsubscribe. If the modified files are
+// invalid, the current definitions are kept and the event carries the error. Outside localhost mode the channel
+// is closed right away
+func (f *SplitFactory) LocalhostUpdates() (<-chan localhost.UpdateEvent, func()) {
+	if f.localhostReloader == nil {
+		updates := make(chan localhost.UpdateEvent)
+		close(updates)
+		return updates, func() {}
+	}
+	return f.localhostReloader.Subscribe()
+}
+
+// Recorder returns the impressions and events generated in localhost mode, which can be inspected and reset to
+// assert on them. Impressions are recorded as they would be sent, so in optimized mode repeated impressions are
+// deduped. It returns nil outside localhost mode
+func (f *SplitFactory) Recorder() *localhost.Recorder {
+	return f.localhostRecorder
+}
+
+// Flush synchronously submits the queued impressions, impression counts, unique keys, events and telemetry.
+// Impressions waiting in the impression listener queue are delivered first, and buffered impressions are then
+// handed to batch impression listeners. In redis-consumer mode data is written on each call, so only impression
+// listeners are flushed. It's a no-op in localhost mode.
+// If ctx is done before finishing, Flush returns the context error right away and no further submission is
+// started. A submission already in progress can't be cancelled and finishes in background
+func (f *SplitFactory) Flush(ctx context.Context) error {
+	if f.IsDestroyed() {
+		return errors.New("Client has already been destroyed - no calls possible")
+	}
+
+	if f.cfg.OperationMode == conf.Localhost {
+		return nil
+	}
+
+	if f.impressionListener != nil {
+		if err := f.impressionListener.Drain(ctx); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f.flush(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush submits every kind of queued data, collecting the errors. Once ctx is done, the remaining kinds are skipped
+func (f *SplitFactory) flush(ctx context.Context) error {
+	errs := make([]error, 0)
+	record := func(name string, fn func() error) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", name, err.Error()))
+		}
+	}
+
+	if f.impressionListener != nil {
+		f.impressionListener.Flush()
+	}
+
+	if f.workers != nil {
+		advanced := conf.NormalizeSDKConf(f.cfg.Advanced)
+		if f.workers.ImpressionRecorder != nil {
+			record("impressions", func() error { return f.workers.ImpressionRecorder.FlushImpressions(advanced.ImpressionsBulkSize) })
+		}
+		if f.workers.ImpressionsCountRecorder != nil {
+			record("impressions count", f.workers.ImpressionsCountRecorder.SynchronizeImpressionsCount)
+		}
+		if f.workers.EventRecorder != nil {
+			record("events", func() error { return f.workers.EventRecorder.FlushEvents(advanced.EventsBulkSize) })
+		}
+		if f.workers.TelemetryRecorder != nil {
+			record("telemetry", f.workers.TelemetryRecorder.SynchronizeTelemetry)
+		}
 	}
 
-	f.syncManager.Stop()
+	if f.uniqueKeysRecorder != nil {
+		record("unique keys", f.uniqueKeysRecorder.SynchronizeUniqueKeys)
+	}
+
+	if len(errs) > 0 {
+		return &MultiError{Operation: "Flush", Errors: errs}
+	}
+	return nil
+}
+
+// isListenerEnabled returns true if at least one impression listener has been configured
+func isListenerEnabled(cfg *conf.SplitSdkConfig) bool {
+	return cfg.Advanced.ImpressionListener != nil || len(cfg.Advanced.ImpressionListeners) > 0
+}
+
+// newImpressionManager builds the impression manager for the configured impressions mode. Impressions counter
+// and unique keys tracker are optional. Processed impressions are always handed back for the listener, since the
+// client dedupes the ones with properties from them, with the factory's impression observer
+func newImpressionManager(
+	cfg *conf.SplitSdkConfig,
+	impressionsCounter *provisional.ImpressionsCounter,
+	uniqueKeysTracker *uniquekeys.Tracker,
+) (provisional.ImpressionManager, error) {
+	var impressionManager provisional.ImpressionManager
+	if cfg.ImpressionsMode == conf.ImpressionsModeNone {
+		impressionManager = uniquekeys.NewImpressionManager(impressionsCounter, uniqueKeysTracker, true)
+	} else {
+		var err error
+		impressionManager, err = provisional.NewImpressionManager(config.ManagerConfig{
+			OperationMode:   cfg.OperationMode,
+			ImpressionsMode: cfg.ImpressionsMode,
+			ListenerEnabled: true,
+		}, impressionsCounter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.Advanced.ImpressionsDisabledFeatures) > 0 {
+		impressionManager = newImpressionsDisabledManager(
+			impressionManager,
+			impressionsCounter,
+			cfg.Advanced.IsImpressionsDisabled,
+			true,
+		)
+	}
+	return impressionManager, nil
 }
 
 // setupLogger sets up the logger according to the parameters submitted by the sdk user
@@ -242,24 +604,20 @@ func setupInMemoryFactory(
 	*/
 
 	inMememoryFullQueue := make(chan string, 2) // Size 2: So that it's able to accept one event from each resource simultaneously.
-	splitsStorage := mutexmap.NewMMSplitStorage()
+	splitsStorage := newNotifyingSplitStorage(mutexmap.NewMMSplitStorage())
 	segmentsStorage := mutexmap.NewMMSegmentStorage()
-	impressionsStorage := mutexqueue.NewMQImpressionsStorage(cfg.Advanced.ImpressionsQueueSize, inMememoryFullQueue, logger)
+	impressionsStorage := impressionproperties.NewQueue(cfg.Advanced.ImpressionsQueueSize, inMememoryFullQueue, logger)
 	telemetryStorage := mutexmap.NewMMMetricsStorage()
 	eventsStorage := mutexqueue.NewMQEventsStorage(cfg.Advanced.EventsQueueSize, inMememoryFullQueue, logger)
 	metricsWrapper := storage.NewMetricWrapper(telemetryStorage, nil, logger)
 
-	managerConfig := config.ManagerConfig{
-		ImpressionsMode: cfg.ImpressionsMode,
-		OperationMode:   cfg.OperationMode,
-		ListenerEnabled: cfg.Advanced.ImpressionListener != nil,
-	}
 	splitAPI := service.NewSplitAPI(apikey, advanced, logger, metadata)
+	eventsClient := api.NewHTTPClient(apikey, advanced, advanced.EventsURL, logger, metadata)
 	workers := synchronizer.Workers{
 		SplitFetcher:       split.NewSplitFetcher(splitsStorage, splitAPI.SplitFetcher, metricsWrapper, logger),
 		SegmentFetcher:     segment.NewSegmentFetcher(splitsStorage, segmentsStorage, splitAPI.SegmentFetcher, metricsWrapper, logger),
 		EventRecorder:      event.NewEventRecorderSingle(eventsStorage, splitAPI.EventRecorder, metricsWrapper, logger, metadata),
-		ImpressionRecorder: impression.NewRecorderSingle(impressionsStorage, splitAPI.ImpressionRecorder, metricsWrapper, logger, metadata, managerConfig),
+		ImpressionRecorder: impressionproperties.NewRecorder(impressionsStorage, eventsClient, cfg.ImpressionsMode, logger),
 		TelemetryRecorder:  metric.NewRecorderSingle(telemetryStorage, splitAPI.MetricRecorder, metadata),
 	}
 	splitTasks := synchronizer.SplitTasks{
@@ -270,12 +628,31 @@ func setupInMemoryFactory(
 		TelemetrySyncTask:  tasks.NewRecordTelemetryTask(workers.TelemetryRecorder, cfg.TaskPeriods.LatencySync, logger),
 	}
 	var impressionsCounter *provisional.ImpressionsCounter
-	if cfg.ImpressionsMode == config.ImpressionsModeOptimized {
+	if cfg.ImpressionsMode == config.ImpressionsModeOptimized || cfg.ImpressionsMode == conf.ImpressionsModeNone || len(cfg.Advanced.ImpressionsDisabledFeatures) > 0 {
 		impressionsCounter = provisional.NewImpressionsCounter()
 		workers.ImpressionsCountRecorder = impressionscount.NewRecorderSingle(impressionsCounter, splitAPI.ImpressionRecorder, metadata, logger)
 		splitTasks.ImpressionsCountSyncTask = tasks.NewRecordImpressionsCountTask(workers.ImpressionsCountRecorder, logger)
 	}
-	impressionManager, err := provisional.NewImpressionManager(managerConfig, impressionsCounter)
+
+	var uniqueKeysTracker *uniquekeys.Tracker
+	var uniqueKeysRecorder *uniquekeys.Recorder
+	var uniqueKeysTask *asynctask.AsyncTask
+	if cfg.ImpressionsMode == conf.ImpressionsModeNone {
+		uniqueKeysTracker = uniquekeys.NewTracker(cfg.Advanced.UniqueKeysCacheSize, func() {
+			// Flush ahead of schedule once the tracker is full. The task is only started once the SDK is ready,
+			// keys tracked before that wait for its first run
+			if uniqueKeysTask != nil && uniqueKeysTask.IsRunning() {
+				uniqueKeysTask.WakeUp()
+			}
+		})
+		uniqueKeysRecorder = uniquekeys.NewRecorder(
+			uniqueKeysTracker,
+			eventsClient,
+			logger,
+		)
+		uniqueKeysTask = uniquekeys.NewRecordUniqueKeysTask(uniqueKeysRecorder, cfg.TaskPeriods.UniqueKeysSync, logger)
+	}
+	impressionManager, err := newImpressionManager(cfg, impressionsCounter, uniqueKeysTracker)
 	if err != nil {
 		return nil, err
 	}
@@ -316,6 +693,9 @@ func setupInMemoryFactory(
 		},
 		readinessSubscriptors: make(map[int]chan int),
 		syncManager:           syncManager,
+		uniqueKeysTask:        uniqueKeysTask,
+		uniqueKeysRecorder:    uniqueKeysRecorder,
+		workers:               &workers,
 	}
 	splitFactory.status.Store(sdkStatusInitializing)
 	splitFactory.impressionManager = impressionManager
@@ -334,8 +714,8 @@ func setupRedisFactory(apikey string, cfg *conf.SplitSdkConfig, logger logging.L
 
 	storages := sdkStorages{
 		splits:      redis.NewSplitStorage(redisClient, logger),
-		segments:    redis.NewSegmentStorage(redisClient, logger),
-		impressions: redis.NewImpressionStorage(redisClient, metadata, logger),
+		segments:    newRedisSegmentStorage(redis.NewSegmentStorage(redisClient, logger), redisClient),
+		impressions: impressionproperties.NewRedisStorage(redisClient, metadata, logger),
 		telemetry:   redis.NewMetricsStorage(redisClient, metadata, logger),
 		events:      redis.NewEventsStorage(redisClient, metadata, logger),
 	}
@@ -347,13 +727,10 @@ func setupRedisFactory(apikey string, cfg *conf.SplitSdkConfig, logger logging.L
 		logger:                logger,
 		operationMode:         conf.RedisConsumer,
 		storages:              storages,
+		redisClient:           redisClient,
 		readinessSubscriptors: make(map[int]chan int),
 	}
-	impressionManager, err := provisional.NewImpressionManager(config.ManagerConfig{
-		OperationMode:   cfg.OperationMode,
-		ImpressionsMode: cfg.ImpressionsMode,
-		ListenerEnabled: cfg.Advanced.ImpressionListener != nil,
-	}, nil)
+	impressionManager, err := newImpressionManager(cfg, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -362,25 +739,64 @@ func setupRedisFactory(apikey string, cfg *conf.SplitSdkConfig, logger logging.L
 	return factory, nil
 }
 
+// newLocalhostSplitFetcher returns the fetcher reading the splits from the source set in the config
+func newLocalhostSplitFetcher(
+	cfg *conf.SplitSdkConfig,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) (*localhost.FileSplitFetcher, error) {
+	data := cfg.SplitData
+	if cfg.SplitReader != nil {
+		var err error
+		data, err = ioutil.ReadAll(cfg.SplitReader)
+		if err != nil {
+			return nil, fmt.Errorf("SplitReader: %s", err.Error())
+		}
+	}
+
+	switch {
+	case data != nil:
+		return localhost.NewDataSplitFetcher(data, cfg.SplitFS, cfg.SegmentDirectory, segmentStorage, logger), nil
+	case len(cfg.SplitFiles) > 0:
+		return localhost.NewFilesSplitFetcher(cfg.SplitFS, cfg.SplitFiles, cfg.SegmentDirectory, segmentStorage, logger), nil
+	case cfg.SplitFS != nil:
+		return localhost.NewFSSplitFetcher(cfg.SplitFS, cfg.SplitFile, cfg.SegmentDirectory, segmentStorage, logger), nil
+	default:
+		return localhost.NewFileSplitFetcher(cfg.SplitFile, cfg.SegmentDirectory, segmentStorage, logger), nil
+	}
+}
+
 func setupLocalhostFactory(
 	apikey string,
 	cfg *conf.SplitSdkConfig,
 	logger logging.LoggerInterface,
 	metadata dtos.Metadata,
 ) (*SplitFactory, error) {
-	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage := newNotifyingSplitStorage(mutexmap.NewMMSplitStorage())
+	segmentStorage := mutexmap.NewMMSegmentStorage()
 	splitPeriod := cfg.TaskPeriods.SplitSync
+	reloadPeriod := localhostReloadPeriod
+	if cfg.TaskPeriods.SplitSyncPeriod > 0 && cfg.TaskPeriods.SplitSyncPeriod < reloadPeriod {
+		reloadPeriod = cfg.TaskPeriods.SplitSyncPeriod
+	}
 	readyChannel := make(chan int, 1)
 
+	splitFetcher, err := newLocalhostSplitFetcher(cfg, segmentStorage, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	localSync := synchronizer.NewLocal(
+		splitPeriod,
+		&service.SplitAPI{
+			SplitFetcher: splitFetcher,
+		},
+		splitStorage,
+		logger,
+	)
+
 	syncManager, err := synchronizer.NewSynchronizerManager(
-		synchronizer.NewLocal(
-			splitPeriod,
-			&service.SplitAPI{
-				SplitFetcher: local.NewFileSplitFetcher(cfg.SplitFile, logger),
-			},
-			splitStorage,
-			logger,
-		),
+		localSync,
 		logger,
 		config.AdvancedConfig{},
 		nil,
@@ -392,6 +808,11 @@ func setupLocalhostFactory(
 		return nil, err
 	}
 
+	recorder, err := localhost.NewRecorder(cfg.Advanced.ImpressionsQueueSize, cfg.Advanced.EventsQueueSize, cfg.RecordFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
 	splitFactory := &SplitFactory{
 		apikey:   apikey,
 		cfg:      cfg,
@@ -399,21 +820,25 @@ func setupLocalhostFactory(
 		logger:   logger,
 		storages: sdkStorages{
 			splits:      splitStorage,
-			impressions: mutexqueue.NewMQImpressionsStorage(cfg.Advanced.ImpressionsQueueSize, make(chan string, 1), logger),
+			impressions: recorder,
 			telemetry:   mutexmap.NewMMMetricsStorage(),
-			events:      mutexqueue.NewMQEventsStorage(cfg.Advanced.EventsQueueSize, make(chan string, 1), logger),
-			segments:    mutexmap.NewMMSegmentStorage(),
+			events:      recorder,
+			segments:    segmentStorage,
 		},
 		readinessSubscriptors: make(map[int]chan int),
 		syncManager:           syncManager,
+		localhostRecorder:     recorder,
+		localhostSync:         localSync,
+		localhostReloader: localhost.NewReloader(
+			splitFetcher,
+			func() error { return localSync.SynchronizeSplits(nil) },
+			reloadPeriod,
+			logger,
+		),
 	}
 	splitFactory.status.Store(sdkStatusInitializing)
 
-	impressionManager, err := provisional.NewImpressionManager(config.ManagerConfig{
-		OperationMode:   cfg.OperationMode,
-		ImpressionsMode: cfg.ImpressionsMode,
-		ListenerEnabled: cfg.Advanced.ImpressionListener != nil,
-	}, nil)
+	impressionManager, err := newImpressionManager(cfg, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -451,11 +876,25 @@ func newFactory(apikey string, cfg *conf.SplitSdkConfig, logger logging.LoggerIn
 	if err != nil {
 		return nil, err
 	}
+	splitFactory.impressionObserver = impressionproperties.NewObserver(impressionObserverSize)
 
-	if cfg.Advanced.ImpressionListener != nil {
-		splitFactory.impressionListener = impressionlistener.NewImpressionListenerWrapper(
-			cfg.Advanced.ImpressionListener,
+	if isListenerEnabled(cfg) {
+		listeners := make([]impressionlistener.FilteredListener, 0, len(cfg.Advanced.ImpressionListeners)+1)
+		if cfg.Advanced.ImpressionListener != nil {
+			listeners = append(listeners, impressionlistener.FilteredListener{Listener: cfg.Advanced.ImpressionListener})
+		}
+		listeners = append(listeners, cfg.Advanced.ImpressionListeners...)
+		splitFactory.impressionListener = impressionlistener.NewMultiImpressionListenerWrapper(
+			listeners,
 			metadata,
+			impressionlistener.Options{
+				QueueSize:      cfg.Advanced.ImpressionListenerQueueSize,
+				Workers:        cfg.Advanced.ImpressionListenerWorkers,
+				OverflowPolicy: cfg.Advanced.ImpressionListenerOverflowPolicy,
+				BatchSize:      cfg.Advanced.ImpressionListenerBatchSize,
+				FlushInterval:  cfg.Advanced.ImpressionListenerFlushInterval,
+			},
+			logger,
 		)
 	}
 
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/provisional"
+)
+
+// impressionsDisabledManager wraps an impression manager so that impressions of the features with impressions
+// disabled are only counted and handed to the listener, but never logged
+type impressionsDisabledManager struct {
+	manager         provisional.ImpressionManager
+	counter         *provisional.ImpressionsCounter
+	isDisabled      func(feature string) bool
+	listenerEnabled bool
+}
+
+func newImpressionsDisabledManager(
+	manager provisional.ImpressionManager,
+	counter *provisional.ImpressionsCounter,
+	isDisabled func(feature string) bool,
+	listenerEnabled bool,
+) *impressionsDisabledManager {
+	return &impressionsDisabledManager{
+		manager:         manager,
+		counter:         counter,
+		isDisabled:      isDisabled,
+		listenerEnabled: listenerEnabled,
+	}
+}
+
+// ProcessImpressions counts the impressions of disabled features and lets the wrapped manager process the rest
+func (m *impressionsDisabledManager) ProcessImpressions(impressions []dtos.Impression) ([]dtos.Impression, []dtos.Impression) {
+	enabled := make([]dtos.Impression, 0, len(impressions))
+	var disabled []dtos.Impression
+	for _, impression := range impressions {
+		if !m.isDisabled(impression.FeatureName) {
+			enabled = append(enabled, impression)
+			continue
+		}
+		if m.counter != nil {
+			m.counter.Inc(impression.FeatureName, impression.Time, 1)
+		}
+		disabled = append(disabled, impression)
+	}
+
+	if len(disabled) == 0 {
+		return m.manager.ProcessImpressions(impressions)
+	}
+
+	var forLog, forListener []dtos.Impression
+	if len(enabled) > 0 {
+		forLog, forListener = m.manager.ProcessImpressions(enabled)
+	}
+	if m.listenerEnabled {
+		forListener = append(forListener, disabled...)
+	}
+	return forLog, forListener
+}
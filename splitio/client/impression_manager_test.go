@@ -0,0 +1,46 @@
+package client
+
+import (
+	"testing"
+
+	commonsCfg "github.com/splitio/go-split-commons/conf"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/provisional"
+)
+
+func TestImpressionsDisabledManager(t *testing.T) {
+	counter := provisional.NewImpressionsCounter()
+	inner, _ := provisional.NewImpressionManager(commonsCfg.ManagerConfig{
+		ImpressionsMode: commonsCfg.ImpressionsModeDebug,
+		OperationMode:   "inmemory-standalone",
+		ListenerEnabled: true,
+	}, nil)
+	manager := newImpressionsDisabledManager(inner, counter, func(feature string) bool { return feature == "disabled" }, true)
+
+	forLog, forListener := manager.ProcessImpressions([]dtos.Impression{
+		{FeatureName: "enabled", KeyName: "user1", Time: 1},
+		{FeatureName: "disabled", KeyName: "user1", Time: 1},
+		{FeatureName: "disabled", KeyName: "user2", Time: 1},
+	})
+	if len(forLog) != 1 || forLog[0].FeatureName != "enabled" {
+		t.Error("Only impressions of enabled features should be logged")
+	}
+	if len(forListener) != 3 {
+		t.Error("Every impression should reach the listener")
+	}
+
+	counts := counter.PopAll()
+	if len(counts) != 1 {
+		t.Error("Only impressions of disabled features should be counted")
+	}
+	for _, count := range counts {
+		if count != 2 {
+			t.Error("Every impression of disabled features should be counted. Actual:", count)
+		}
+	}
+
+	forLog, forListener = manager.ProcessImpressions([]dtos.Impression{{FeatureName: "disabled", KeyName: "user1", Time: 1}})
+	if len(forLog) != 0 || len(forListener) != 1 {
+		t.Error("Impressions of disabled features should not be logged")
+	}
+}
@@ -251,12 +251,22 @@ func (i *inputValidation) ValidateFeatureNames(features []string, operation stri
 }
 
 func (i *inputValidation) validateTrackProperties(properties map[string]interface{}) (map[string]interface{}, int, error) {
+	return i.validateProperties(properties, "Track: Event", "Event not queued")
+}
+
+// validateImpressionProperties applies the same rules used for event properties to the properties attached to an evaluation
+func (i *inputValidation) validateImpressionProperties(properties map[string]interface{}, operation string) (map[string]interface{}, error) {
+	processed, _, err := i.validateProperties(properties, operation+": Impression", "Properties discarded")
+	return processed, err
+}
+
+func (i *inputValidation) validateProperties(properties map[string]interface{}, subject string, outcome string) (map[string]interface{}, int, error) {
 	if len(properties) == 0 {
 		return nil, 0, nil
 	}
 
 	if len(properties) > 300 {
-		i.logger.Warning("Track: Event has more than 300 properties. Some of them will be trimmed when processed")
+		i.logger.Warning(subject + " has more than 300 properties. Some of them will be trimmed when processed")
 	}
 
 	processed := make(map[string]interface{})
@@ -277,9 +287,9 @@ func (i *inputValidation) validateTrackProperties(properties map[string]interfac
 
 		if size > MaxEventLength {
 			i.logger.Error(
-				"The maximum size allowed for the properties is 32kb. Event not queued",
+				"The maximum size allowed for the properties is 32kb. " + outcome,
 			)
-			return nil, size, errors.New("The maximum size allowed for the properties is 32kb. Event not queued")
+			return nil, size, errors.New("The maximum size allowed for the properties is 32kb. " + outcome)
 		}
 	}
 	return processed, size, nil
@@ -1,8 +1,16 @@
 package client
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"time"
 
+	dependencygraph "github.com/splitio/go-client/splitio/dependencyGraph"
+	"github.com/splitio/go-client/splitio/engine/grammar/matchers"
+	"github.com/splitio/go-client/splitio/overrides"
+	"github.com/splitio/go-client/splitio/snapshot"
 	"github.com/splitio/go-split-commons/dtos"
 	"github.com/splitio/go-split-commons/storage"
 	"github.com/splitio/go-toolkit/logging"
@@ -10,39 +18,103 @@ import (
 
 // SplitManager provides information of the currently stored splits
 type SplitManager struct {
-	splitStorage storage.SplitStorageConsumer
-	validator    inputValidation
-	logger       logging.LoggerInterface
-	factory      *SplitFactory
+	splitStorage   storage.SplitStorageConsumer
+	segmentStorage storage.SegmentStorageConsumer
+	overrides      *overrides.Store
+	validator      inputValidation
+	logger         logging.LoggerInterface
+	factory        *SplitFactory
+	watchInterval  time.Duration
 }
 
 // SplitView is a partial representation of a currently stored split
 type SplitView struct {
-	Name         string            `json:"name"`
-	TrafficType  string            `json:"trafficType"`
-	Killed       bool              `json:"killed"`
-	Treatments   []string          `json:"treatments"`
-	ChangeNumber int64             `json:"changeNumber"`
-	Configs      map[string]string `json:"configs"`
+	Name                string            `json:"name"`
+	TrafficType         string            `json:"trafficType"`
+	Killed              bool              `json:"killed"`
+	Treatments          []string          `json:"treatments"`
+	ChangeNumber        int64             `json:"changeNumber"`
+	Configs             map[string]string `json:"configs"`
+	ImpressionsDisabled bool              `json:"impressionsDisabled"`
+	DefaultTreatment    string            `json:"defaultTreatment"`
+	Segments            []string          `json:"segments"`
+	Dependencies        []string          `json:"dependencies"`
+	TrafficAllocation   int               `json:"trafficAllocation"`
+	Algo                int               `json:"algo"`
+	Status              string            `json:"status"`
+}
+
+// SegmentView is a summary of a currently stored segment
+type SegmentView struct {
+	Name         string `json:"name"`
+	ChangeNumber int64  `json:"changeNumber"`
+	KeyCount     int    `json:"keyCount"`
 }
 
 func newSplitView(splitDto *dtos.SplitDTO) *SplitView {
-	treatments := make([]string, 0)
+	treatments := newUniqueList()
+	segments := newUniqueList()
+	dependencies := newUniqueList()
 	for _, condition := range splitDto.Conditions {
 		for _, partition := range condition.Partitions {
-			treatments = append(treatments, partition.Treatment)
+			treatments.add(partition.Treatment)
+		}
+		for _, matcher := range condition.MatcherGroup.Matchers {
+			switch matcher.MatcherType {
+			case matchers.MatcherTypeInSegment:
+				if matcher.UserDefinedSegment != nil {
+					segments.add(matcher.UserDefinedSegment.SegmentName)
+				}
+			case matchers.MatcherTypeInSplitTreatment:
+				if matcher.Dependency != nil {
+					dependencies.add(matcher.Dependency.Split)
+				}
+			}
 		}
 	}
 	return &SplitView{
-		ChangeNumber: splitDto.ChangeNumber,
-		Killed:       splitDto.Killed,
-		Name:         splitDto.Name,
-		TrafficType:  splitDto.TrafficTypeName,
-		Treatments:   treatments,
-		Configs:      splitDto.Configurations,
+		ChangeNumber:      splitDto.ChangeNumber,
+		Killed:            splitDto.Killed,
+		Name:              splitDto.Name,
+		TrafficType:       splitDto.TrafficTypeName,
+		Treatments:        treatments.items,
+		Configs:           splitDto.Configurations,
+		DefaultTreatment:  splitDto.DefaultTreatment,
+		Segments:          segments.items,
+		Dependencies:      dependencies.items,
+		TrafficAllocation: splitDto.TrafficAllocation,
+		Algo:              splitDto.Algo,
+		Status:            splitDto.Status,
 	}
 }
 
+// uniqueList keeps the items added to it in order of first appearance, skipping duplicates
+type uniqueList struct {
+	items []string
+	seen  map[string]struct{}
+}
+
+func newUniqueList() *uniqueList {
+	return &uniqueList{items: make([]string, 0), seen: make(map[string]struct{})}
+}
+
+func (u *uniqueList) add(item string) {
+	if _, ok := u.seen[item]; ok {
+		return
+	}
+	u.seen[item] = struct{}{}
+	u.items = append(u.items, item)
+}
+
+// newSplitView builds the view of a split including the settings configured in the sdk
+func (m *SplitManager) newSplitView(splitDto *dtos.SplitDTO) *SplitView {
+	view := newSplitView(splitDto)
+	if m.factory != nil && m.factory.cfg != nil {
+		view.ImpressionsDisabled = m.factory.cfg.Advanced.IsImpressionsDisabled(splitDto.Name)
+	}
+	return view
+}
+
 // SplitNames returns a list with the name of all the currently stored splits
 func (m *SplitManager) SplitNames() []string {
 	if m.isDestroyed() {
@@ -71,7 +143,7 @@ func (m *SplitManager) Splits() []SplitView {
 	splitViews := make([]SplitView, 0)
 	splits := m.splitStorage.All()
 	for _, split := range splits {
-		splitViews = append(splitViews, *newSplitView(&split))
+		splitViews = append(splitViews, *m.newSplitView(&split))
 	}
 	return splitViews
 }
@@ -95,17 +167,207 @@ func (m *SplitManager) Split(feature string) *SplitView {
 
 	split := m.splitStorage.Split(feature)
 	if split != nil {
-		return newSplitView(split)
+		return m.newSplitView(split)
 	}
 	m.logger.Error(fmt.Sprintf("Split: you passed %s that does not exist in this environment, please double check what Splits exist in the web console.", feature))
 	return nil
 }
 
+// SegmentNames returns the sorted names of the segments referenced by the currently stored splits that are stored
+// as well. Segments not synchronized yet are left out
+func (m *SplitManager) SegmentNames() []string {
+	if m.isDestroyed() {
+		m.logger.Error("Client has already been destroyed - no calls possible")
+		return []string{}
+	}
+
+	if !m.isReady() {
+		m.logger.Warning("segmentNames: the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
+	}
+
+	segmentNames := make([]string, 0)
+	for _, name := range m.splitStorage.SegmentNames().List() {
+		segmentName, ok := name.(string)
+		if !ok {
+			continue
+		}
+		exists, err := m.segmentExists(segmentName)
+		if err != nil {
+			m.logger.Error(fmt.Sprintf("SegmentNames: error checking segment %s: %s", segmentName, err.Error()))
+			continue
+		}
+		if exists {
+			segmentNames = append(segmentNames, segmentName)
+		}
+	}
+	sort.Strings(segmentNames)
+	return segmentNames
+}
+
+// Segment returns a summary of a particular segment
+func (m *SplitManager) Segment(name string) *SegmentView {
+	if m.isDestroyed() {
+		m.logger.Error("Client has already been destroyed - no calls possible")
+		return nil
+	}
+
+	if !m.isReady() {
+		m.logger.Warning("segment: the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
+	}
+
+	err := checkIsEmptyString(name, "segment name", "Segment")
+	if err != nil {
+		m.logger.Error(err.Error())
+		return nil
+	}
+
+	exists, err := m.segmentExists(name)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("Segment: error checking segment %s: %s", name, err.Error()))
+		return nil
+	}
+	if !exists {
+		m.logger.Error(fmt.Sprintf("Segment: you passed %s that does not exist in this environment or is not referenced by any split.", name))
+		return nil
+	}
+
+	keyCount, err := m.segmentKeyCount(name)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("Segment: error counting keys of segment %s: %s", name, err.Error()))
+		return nil
+	}
+
+	changeNumber, err := m.segmentStorage.ChangeNumber(name)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("Segment: error fetching change number of segment %s: %s", name, err.Error()))
+		return nil
+	}
+
+	return &SegmentView{
+		Name:         name,
+		ChangeNumber: changeNumber,
+		KeyCount:     int(keyCount),
+	}
+}
+
+// IsKeyInSegment returns true if the key is a member of the segment
+func (m *SplitManager) IsKeyInSegment(name string, key string) bool {
+	if m.isDestroyed() {
+		m.logger.Error("Client has already been destroyed - no calls possible")
+		return false
+	}
+
+	if !m.isReady() {
+		m.logger.Warning("isKeyInSegment: the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
+	}
+
+	err := checkIsEmptyString(name, "segment name", "IsKeyInSegment")
+	if err != nil {
+		m.logger.Error(err.Error())
+		return false
+	}
+
+	err = checkIsEmptyString(key, "key", "IsKeyInSegment")
+	if err != nil {
+		m.logger.Error(err.Error())
+		return false
+	}
+
+	contained, err := m.segmentStorage.SegmentContainsKey(name, key)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("IsKeyInSegment: error checking membership of key %s in segment %s: %s", key, name, err.Error()))
+		return false
+	}
+	return contained
+}
+
+// Snapshot returns the exact split definitions and segment contents currently stored, which can be loaded
+// into a localhost factory to reproduce evaluations offline
+func (m *SplitManager) Snapshot() (*snapshot.Snapshot, error) {
+	if m.isDestroyed() {
+		return nil, errors.New("Client has already been destroyed - no calls possible")
+	}
+
+	if !m.isReady() {
+		m.logger.Warning("snapshot: the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
+	}
+
+	return snapshot.Build(m.splitStorage, m.segmentStorage)
+}
+
+// ExportSnapshot writes the snapshot of the currently stored splits and segments as JSON. The output can be used as
+// SplitFile in localhost mode
+func (m *SplitManager) ExportSnapshot(w io.Writer) error {
+	s, err := m.Snapshot()
+	if err != nil {
+		return err
+	}
+	return s.Write(w)
+}
+
+// DependencyGraph returns the graph of dependencies between the currently stored splits and the segments they
+// reference, including missing targets and cycles
+func (m *SplitManager) DependencyGraph() (*dependencygraph.Graph, error) {
+	if m.isDestroyed() {
+		return nil, errors.New("Client has already been destroyed - no calls possible")
+	}
+
+	if !m.isReady() {
+		m.logger.Warning("dependencyGraph: the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
+	}
+
+	return dependencygraph.Build(m.splitStorage.All(), func(name string) bool {
+		exists, err := m.segmentExists(name)
+		if err != nil {
+			m.logger.Error(fmt.Sprintf("dependencyGraph: error checking segment %s: %s", name, err.Error()))
+		}
+		return exists
+	}), nil
+}
+
+// segmentExists returns true if the segment is stored, without loading its keys when the storage can tell
+func (m *SplitManager) segmentExists(name string) (bool, error) {
+	if counter, ok := m.segmentStorage.(segmentCounter); ok {
+		return counter.SegmentExists(name)
+	}
+	return m.segmentStorage.Keys(name) != nil, nil
+}
+
+// segmentKeyCount returns the number of keys of a stored segment, without loading them when the storage can tell
+func (m *SplitManager) segmentKeyCount(name string) (int64, error) {
+	if counter, ok := m.segmentStorage.(segmentCounter); ok {
+		return counter.SegmentKeyCount(name)
+	}
+	keys := m.segmentStorage.Keys(name)
+	if keys == nil {
+		return 0, nil
+	}
+	return int64(keys.Size()), nil
+}
+
+// Overrides returns the treatments forced through the factory overrides, sorted by feature
+func (m *SplitManager) Overrides() []overrides.Override {
+	if m.isDestroyed() {
+		m.logger.Error("Client has already been destroyed - no calls possible")
+		return []overrides.Override{}
+	}
+
+	if m.overrides == nil {
+		return []overrides.Override{}
+	}
+	return m.overrides.List()
+}
+
 // BlockUntilReady Calls BlockUntilReady on factory to block manager on readiness
 func (m *SplitManager) BlockUntilReady(timer int) error {
 	return m.factory.BlockUntilReady(timer)
 }
 
+// BlockUntilReadyTimeout Calls BlockUntilReadyTimeout on factory to block manager on readiness
+func (m *SplitManager) BlockUntilReadyTimeout(timeout time.Duration) error {
+	return m.factory.BlockUntilReadyTimeout(timeout)
+}
+
 func (m *SplitManager) isDestroyed() bool {
 	return m.factory.IsDestroyed()
 }
@@ -1,10 +1,18 @@
 package client
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/splitio/go-client/splitio/conf"
+	commonsCfg "github.com/splitio/go-split-commons/conf"
 	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/storage"
 	"github.com/splitio/go-split-commons/storage/mutexmap"
+	"github.com/splitio/go-split-commons/storage/redis"
 	"github.com/splitio/go-toolkit/datastructures/set"
 	"github.com/splitio/go-toolkit/logging"
 )
@@ -159,3 +167,447 @@ func TestSplitManagerWithConfigs(t *testing.T) {
 		t.Error("Nonexistent split should return nil")
 	}
 }
+
+func TestSplitManagerImpressionsDisabled(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{
+		{Name: "kill_switch_checkout", TrafficTypeName: "user"},
+		{Name: "split2", TrafficTypeName: "user"},
+	}, 123)
+
+	logger := logging.NewLogger(nil)
+	cfg := conf.Default()
+	cfg.Advanced.ImpressionsDisabledFeatures = []string{"kill_switch_*"}
+	factory := SplitFactory{cfg: cfg}
+	manager := SplitManager{
+		splitStorage: splitStorage,
+		validator:    inputValidation{logger: logger},
+		logger:       logger,
+		factory:      &factory,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	if !manager.Split("kill_switch_checkout").ImpressionsDisabled {
+		t.Error("Impressions should be disabled for kill_switch_checkout")
+	}
+	if manager.Split("split2").ImpressionsDisabled {
+		t.Error("Impressions should be enabled for split2")
+	}
+	for _, view := range manager.Splits() {
+		if view.ImpressionsDisabled != (view.Name == "kill_switch_checkout") {
+			t.Error("Unexpected impressions setting for", view.Name)
+		}
+	}
+}
+
+func TestSplitManagerDetailedView(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{
+		{
+			ChangeNumber:      123,
+			Name:              "split1",
+			TrafficTypeName:   "user",
+			DefaultTreatment:  "off",
+			TrafficAllocation: 50,
+			Algo:              2,
+			Status:            "ACTIVE",
+			Conditions: []dtos.ConditionDTO{
+				{
+					MatcherGroup: dtos.MatcherGroupDTO{
+						Matchers: []dtos.MatcherDTO{
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"}},
+							{MatcherType: "IN_SPLIT_TREATMENT", Dependency: &dtos.DependencyMatcherDataDTO{Split: "parent", Treatments: []string{"on"}}},
+						},
+					},
+					Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}, {Treatment: "off"}},
+				},
+				{
+					MatcherGroup: dtos.MatcherGroupDTO{
+						Matchers: []dtos.MatcherDTO{
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"}},
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "beta"}},
+						},
+					},
+					Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 50}, {Treatment: "off", Size: 50}},
+				},
+			},
+		},
+	}, 123)
+
+	logger := logging.NewLogger(nil)
+	factory := SplitFactory{}
+	manager := SplitManager{
+		splitStorage: splitStorage,
+		validator:    inputValidation{logger: logger},
+		logger:       logger,
+		factory:      &factory,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	view := manager.Split("split1")
+	if len(view.Treatments) != 2 || view.Treatments[0] != "on" || view.Treatments[1] != "off" {
+		t.Error("Treatments should not be repeated. Actual:", view.Treatments)
+	}
+	if view.DefaultTreatment != "off" || view.TrafficAllocation != 50 || view.Algo != 2 || view.Status != "ACTIVE" {
+		t.Error("Unexpected split settings in view", view)
+	}
+	if len(view.Segments) != 2 || view.Segments[0] != "employees" || view.Segments[1] != "beta" {
+		t.Error("Unexpected segments. Actual:", view.Segments)
+	}
+	if len(view.Dependencies) != 1 || view.Dependencies[0] != "parent" {
+		t.Error("Unexpected dependencies. Actual:", view.Dependencies)
+	}
+}
+
+func TestSplitManagerSegments(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{
+		{
+			Name: "split1",
+			Conditions: []dtos.ConditionDTO{
+				{
+					MatcherGroup: dtos.MatcherGroupDTO{
+						Matchers: []dtos.MatcherDTO{
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"}},
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "beta"}},
+						},
+					},
+				},
+			},
+		},
+	}, 123)
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	segmentStorage.Update("employees", set.NewSet("user1", "user2", "user3"), set.NewSet(), 456)
+
+	logger := logging.NewLogger(nil)
+	factory := SplitFactory{}
+	manager := SplitManager{
+		splitStorage:   splitStorage,
+		segmentStorage: segmentStorage,
+		validator:      inputValidation{logger: logger},
+		logger:         logger,
+		factory:        &factory,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	names := manager.SegmentNames()
+	if len(names) != 1 || names[0] != "employees" {
+		t.Error("Only stored segments should be listed. Actual:", names)
+	}
+
+	segment := manager.Segment("employees")
+	if segment == nil || segment.Name != "employees" || segment.ChangeNumber != 456 || segment.KeyCount != 3 {
+		t.Error("Unexpected segment view", segment)
+	}
+	if manager.Segment("beta") != nil {
+		t.Error("Segments not yet stored should return nil")
+	}
+	if manager.Segment("") != nil {
+		t.Error("Empty segment name should return nil")
+	}
+
+	if !manager.IsKeyInSegment("employees", "user2") {
+		t.Error("user2 should be in employees")
+	}
+	if manager.IsKeyInSegment("employees", "user4") || manager.IsKeyInSegment("beta", "user2") {
+		t.Error("Key should not be in segment")
+	}
+
+	factory.status.Store(sdkStatusDestroyed)
+	if len(manager.SegmentNames()) != 0 || manager.Segment("employees") != nil || manager.IsKeyInSegment("employees", "user2") {
+		t.Error("Destroyed manager should return empty results")
+	}
+}
+
+func TestSplitManagerSegmentsRedis(t *testing.T) {
+	prefixedClient, _ := redis.NewRedisClient(&commonsCfg.RedisConfig{
+		Host:     "localhost",
+		Port:     6379,
+		Database: 1,
+		Password: "",
+		Prefix:   "testPrefix",
+	}, logging.NewLogger(&logging.LoggerOptions{}))
+	prefixedClient.SAdd("SPLITIO.segment.employees", "user1", "user2", "user3")
+	prefixedClient.Set("SPLITIO.segment.employees.till", 456, 0)
+	prefixedClient.Set("SPLITIO.segment.empty.till", 789, 0)
+	defer prefixedClient.Del("SPLITIO.segment.employees", "SPLITIO.segment.employees.till", "SPLITIO.segment.empty.till")
+
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{
+		{
+			Name: "split1",
+			Conditions: []dtos.ConditionDTO{
+				{
+					MatcherGroup: dtos.MatcherGroupDTO{
+						Matchers: []dtos.MatcherDTO{
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"}},
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "empty"}},
+							{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "beta"}},
+						},
+					},
+				},
+			},
+		},
+	}, 123)
+
+	logger := logging.NewLogger(nil)
+	factory := SplitFactory{}
+	manager := SplitManager{
+		splitStorage:   splitStorage,
+		segmentStorage: newRedisSegmentStorage(redis.NewSegmentStorage(prefixedClient, logger), prefixedClient),
+		validator:      inputValidation{logger: logger},
+		logger:         logger,
+		factory:        &factory,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	names := manager.SegmentNames()
+	if len(names) != 2 || names[0] != "employees" || names[1] != "empty" {
+		t.Error("Synchronized segments should be listed, even if empty. Actual:", names)
+	}
+
+	segment := manager.Segment("employees")
+	if segment == nil || segment.ChangeNumber != 456 || segment.KeyCount != 3 {
+		t.Error("Unexpected segment view", segment)
+	}
+	segment = manager.Segment("empty")
+	if segment == nil || segment.ChangeNumber != 789 || segment.KeyCount != 0 {
+		t.Error("Unexpected segment view", segment)
+	}
+	if manager.Segment("beta") != nil {
+		t.Error("Segments not yet stored should return nil")
+	}
+}
+
+func TestSplitManagerWatch(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 1, DefaultTreatment: "off"}}, 1)
+
+	logger := logging.NewLogger(nil)
+	factory := SplitFactory{}
+	manager := SplitManager{
+		splitStorage:  splitStorage,
+		validator:     inputValidation{logger: logger},
+		logger:        logger,
+		factory:       &factory,
+		watchInterval: 10 * time.Millisecond,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	updates, cancel := manager.Watch("split1")
+	next := func() (SplitView, bool) {
+		select {
+		case view, ok := <-updates:
+			return view, ok
+		case <-time.After(time.Second):
+			t.Error("Timed out waiting for an update")
+			return SplitView{}, false
+		}
+	}
+
+	select {
+	case <-updates:
+		t.Error("Nothing should be emitted while the split does not change")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	splitStorage.PutMany([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 2, DefaultTreatment: "on"}}, 2)
+	if view, _ := next(); view.ChangeNumber != 2 || view.DefaultTreatment != "on" {
+		t.Error("The updated split should be emitted. Actual:", view)
+	}
+
+	splitStorage.Remove("split1")
+	if view, _ := next(); view.Name != "split1" || view.ChangeNumber != RemovedChangeNumber {
+		t.Error("Removal should be emitted. Actual:", view)
+	}
+
+	cancel()
+	cancel()
+	for range updates {
+	}
+
+	updates, _ = manager.Watch("split1")
+	factory.status.Store(sdkStatusDestroyed)
+	if _, ok := next(); ok {
+		t.Error("The channel should be closed when the factory is destroyed")
+	}
+
+	updates, _ = manager.Watch("split2")
+	if _, ok := <-updates; ok {
+		t.Error("Watching on a destroyed factory should return a closed channel")
+	}
+}
+
+func TestSplitManagerWatchNotified(t *testing.T) {
+	splitStorage := newNotifyingSplitStorage(mutexmap.NewMMSplitStorage())
+	splitStorage.PutMany([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 1, DefaultTreatment: "off"}}, 1)
+
+	logger := logging.NewLogger(nil)
+	factory := SplitFactory{}
+	manager := SplitManager{
+		splitStorage:  splitStorage,
+		validator:     inputValidation{logger: logger},
+		logger:        logger,
+		factory:       &factory,
+		watchInterval: time.Hour,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	updates, cancel := manager.Watch("split1")
+	defer cancel()
+
+	splitStorage.PutMany([]dtos.SplitDTO{{Name: "split2", ChangeNumber: 2}}, 2)
+	splitStorage.PutMany([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 3, DefaultTreatment: "on"}}, 3)
+	select {
+	case view := <-updates:
+		if view.ChangeNumber != 3 || view.DefaultTreatment != "on" {
+			t.Error("The updated split should be emitted. Actual:", view)
+		}
+	case <-time.After(time.Second):
+		t.Error("Changes should be notified without polling")
+	}
+
+	splitStorage.close()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("The channel should be closed when the storage is closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for the channel to be closed")
+	}
+}
+
+func TestSplitManagerWatchChangeRightAfterWatch(t *testing.T) {
+	for _, notifying := range []bool{true, false} {
+		var splitStorage storage.SplitStorage = mutexmap.NewMMSplitStorage()
+		if notifying {
+			splitStorage = newNotifyingSplitStorage(splitStorage)
+		}
+		splitStorage.PutMany([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 1}}, 1)
+
+		logger := logging.NewLogger(nil)
+		factory := SplitFactory{}
+		manager := SplitManager{
+			splitStorage:  splitStorage,
+			validator:     inputValidation{logger: logger},
+			logger:        logger,
+			factory:       &factory,
+			watchInterval: 10 * time.Millisecond,
+		}
+		factory.status.Store(sdkStatusReady)
+
+		updates, cancel := manager.Watch("split1")
+		splitStorage.PutMany([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 2}}, 2)
+		select {
+		case view := <-updates:
+			if view.ChangeNumber != 2 {
+				t.Error("The change should be emitted. Actual:", view)
+			}
+		case <-time.After(time.Second):
+			t.Error("A change written right after Watch returns should be emitted. Notifying storage:", notifying)
+		}
+		cancel()
+	}
+}
+
+func TestSplitManagerSnapshotRoundTrip(t *testing.T) {
+	source, err := NewSplitFactory("localhost", &conf.SplitSdkConfig{
+		OperationMode: conf.Localhost,
+		SplitFile:     "../../testdata/snapshot.json",
+		TaskPeriods:   conf.Default().TaskPeriods,
+		Advanced:      conf.Default().Advanced,
+	})
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	defer source.Destroy()
+	source.BlockUntilReady(1)
+
+	dir, err := ioutil.TempDir("", "splitio_snapshot")
+	if err != nil {
+		t.Error("Couldn't create temporary directory for snapshot tests: ", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	file, err := os.Create(filepath.Join(dir, "snapshot.json"))
+	if err != nil {
+		t.Error("Couldn't create snapshot file: ", err)
+		return
+	}
+	err = source.Manager().ExportSnapshot(file)
+	file.Close()
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+	}
+
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = file.Name()
+	replay, err := NewSplitFactory("localhost", sdkConf)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	defer replay.Destroy()
+	replay.BlockUntilReady(1)
+
+	client := replay.Client()
+	result := client.TreatmentWithConfig("user1", "employees_feature", nil)
+	if result.Treatment != "on" || result.Config == nil || *result.Config != "{\"color\": \"blue\"}" {
+		t.Error("Keys in the snapshot segment should get on. Actual:", result.Treatment)
+	}
+	if client.Treatment("user3", "employees_feature", nil) != "off" {
+		t.Error("Keys outside the snapshot segment should get off")
+	}
+	segment := replay.Manager().Segment("employees")
+	if segment == nil || segment.KeyCount != 2 || segment.ChangeNumber != 1489542661161 {
+		t.Error("Snapshot segments should be stored. Actual:", segment)
+	}
+
+	replay.Destroy()
+	if _, err = replay.Manager().Snapshot(); err == nil {
+		t.Error("Snapshots should not be taken once destroyed")
+	}
+}
+
+func TestSplitManagerDependencyGraph(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{
+		{
+			Name: "checkout",
+			Conditions: []dtos.ConditionDTO{{MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{
+				{MatcherType: "IN_SPLIT_TREATMENT", Dependency: &dtos.DependencyMatcherDataDTO{Split: "payments", Treatments: []string{"on"}}},
+				{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"}},
+				{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "beta"}},
+			}}}},
+		},
+	}, 123)
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	segmentStorage.Update("employees", set.NewSet("user1"), set.NewSet(), 456)
+
+	logger := logging.NewLogger(nil)
+	factory := SplitFactory{}
+	manager := SplitManager{
+		splitStorage:   splitStorage,
+		segmentStorage: segmentStorage,
+		validator:      inputValidation{logger: logger},
+		logger:         logger,
+		factory:        &factory,
+	}
+	factory.status.Store(sdkStatusReady)
+
+	graph, err := manager.DependencyGraph()
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	if len(graph.Edges) != 3 || len(graph.Missing) != 2 || graph.Missing[0].Name != "payments" || graph.Missing[1].Name != "beta" {
+		t.Error("Missing split and segment should be reported. Actual:", graph.Missing)
+	}
+
+	factory.status.Store(sdkStatusDestroyed)
+	if _, err = manager.DependencyGraph(); err == nil {
+		t.Error("Graph should not be built once destroyed")
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/splitio/go-split-commons/storage"
+	predis "github.com/splitio/go-toolkit/redis"
+)
+
+// Keys written by the segment storage of go-split-commons, which doesn't export them. The till key is set once
+// the segment has been synchronized, even if it has no keys
+const (
+	redisSegmentKey     = "SPLITIO.segment.{segment}"
+	redisSegmentTillKey = "SPLITIO.segment.{segment}.till"
+)
+
+// segmentCounter is implemented by segment storages able to check whether a segment is stored and count its keys
+// without loading them
+type segmentCounter interface {
+	SegmentExists(name string) (bool, error)
+	SegmentKeyCount(name string) (int64, error)
+}
+
+// redisSegmentStorage adds to the redis segment storage of go-split-commons existence and count queries, so that
+// inspecting a segment doesn't fetch its members
+type redisSegmentStorage struct {
+	storage.SegmentStorageConsumer
+	client *predis.PrefixedRedisClient
+}
+
+// newRedisSegmentStorage wraps the redis segment storage
+func newRedisSegmentStorage(segments storage.SegmentStorageConsumer, client *predis.PrefixedRedisClient) *redisSegmentStorage {
+	return &redisSegmentStorage{SegmentStorageConsumer: segments, client: client}
+}
+
+// SegmentExists returns true if the segment has been synchronized
+func (r *redisSegmentStorage) SegmentExists(name string) (bool, error) {
+	count, err := r.client.Exists(strings.Replace(redisSegmentTillKey, "{segment}", name, 1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SegmentKeyCount returns the number of keys stored for the segment
+func (r *redisSegmentStorage) SegmentKeyCount(name string) (int64, error) {
+	return r.client.SCard(strings.Replace(redisSegmentKey, "{segment}", name, 1))
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/storage"
+)
+
+const (
+	watchPeriodInMemory = time.Second
+	watchPeriodRedis    = 5 * time.Second
+	// RemovedChangeNumber is the change number of the view emitted by Watch when the split is removed
+	RemovedChangeNumber int64 = -1
+)
+
+// notifyingSplitStorage wraps the split storage written by the synchronizer to notify its subscribers every time
+// splits are stored, removed or killed, so that watchers don't need to poll it
+type notifyingSplitStorage struct {
+	storage.SplitStorage
+	subscribers map[int]chan struct{}
+	nextID      int
+	closed      bool
+	mutex       sync.Mutex
+}
+
+func newNotifyingSplitStorage(wrapped storage.SplitStorage) *notifyingSplitStorage {
+	return &notifyingSplitStorage{
+		SplitStorage: wrapped,
+		subscribers:  make(map[int]chan struct{}),
+	}
+}
+
+// PutMany stores the splits and notifies the subscribers
+func (s *notifyingSplitStorage) PutMany(splits []dtos.SplitDTO, changeNumber int64) {
+	s.SplitStorage.PutMany(splits, changeNumber)
+	s.notify()
+}
+
+// Remove removes the split and notifies the subscribers
+func (s *notifyingSplitStorage) Remove(splitName string) {
+	s.SplitStorage.Remove(splitName)
+	s.notify()
+}
+
+// KillLocally kills the split and notifies the subscribers
+func (s *notifyingSplitStorage) KillLocally(splitName string, defaultTreatment string, changeNumber int64) {
+	s.SplitStorage.KillLocally(splitName, defaultTreatment, changeNumber)
+	s.notify()
+}
+
+// notify signals every subscriber, without blocking on the ones with a pending signal
+func (s *notifyingSplitStorage) notify() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, subscriber := range s.subscribers {
+		select {
+		case subscriber <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe returns a channel signaled after every change, closed when the storage is closed, and a function to
+// unsubscribe
+func (s *notifyingSplitStorage) subscribe() (<-chan struct{}, func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	changes := make(chan struct{}, 1)
+	if s.closed {
+		close(changes)
+		return changes, func() {}
+	}
+
+	id := s.nextID
+	s.nextID++
+	s.subscribers[id] = changes
+	return changes, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
+// close closes the channel of every subscriber. It's called when the factory is destroyed
+func (s *notifyingSplitStorage) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for id, subscriber := range s.subscribers {
+		close(subscriber)
+		delete(s.subscribers, id)
+	}
+}
+
+// Watch returns a channel that receives a view of the split every time its change number changes in storage,
+// and a function to stop watching. In in-memory and localhost modes the storage notifies changes as soon as they
+// are written, while in redis-consumer mode, where another process writes them, the storage is polled. When the
+// split is removed, a view with only the name set and a change number of RemovedChangeNumber is emitted. Only the
+// latest pending view is kept if the receiver falls behind. The channel is closed when cancel is called or the
+// factory is destroyed
+func (m *SplitManager) Watch(feature string) (<-chan SplitView, func()) {
+	updates := make(chan SplitView, 1)
+	if m.isDestroyed() {
+		m.logger.Error("Client has already been destroyed - no calls possible")
+		close(updates)
+		return updates, func() {}
+	}
+
+	err := m.validator.ValidateManagerInputs(feature)
+	if err != nil {
+		m.logger.Error(err.Error())
+		close(updates)
+		return updates, func() {}
+	}
+
+	// Subscribe and take the baseline before returning, so that changes written right after Watch are emitted
+	var changes <-chan struct{}
+	var ticks <-chan time.Time
+	var release func()
+	if notifier, ok := m.splitStorage.(*notifyingSplitStorage); ok {
+		changes, release = notifier.subscribe()
+	} else {
+		ticker := time.NewTicker(m.watchPeriod())
+		ticks = ticker.C
+		release = ticker.Stop
+	}
+
+	lastChangeNumber := RemovedChangeNumber
+	if split := m.splitStorage.Split(feature); split != nil {
+		lastChangeNumber = split.ChangeNumber
+	}
+
+	stop := make(chan struct{})
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { close(stop) })
+	}
+
+	go m.watch(feature, lastChangeNumber, changes, ticks, release, updates, stop)
+	return updates, cancel
+}
+
+// watchPeriod returns how often the split storage is checked for changes
+func (m *SplitManager) watchPeriod() time.Duration {
+	if m.watchInterval > 0 {
+		return m.watchInterval
+	}
+	if m.factory != nil && m.factory.cfg != nil && m.factory.cfg.OperationMode == conf.RedisConsumer {
+		return watchPeriodRedis
+	}
+	return watchPeriodInMemory
+}
+
+// watch waits for changes notified by changes, or checks the storage on every tick if it doesn't notify them,
+// until stopped. A view is emitted whenever the change number of the split differs from the last one seen,
+// starting from lastChangeNumber. release is called once done
+func (m *SplitManager) watch(
+	feature string,
+	lastChangeNumber int64,
+	changes <-chan struct{},
+	ticks <-chan time.Time,
+	release func(),
+	updates chan SplitView,
+	stop chan struct{},
+) {
+	defer close(updates)
+	defer release()
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error(
+				"SDK is panicking with the following error", r, "\n",
+				string(debug.Stack()), "\n",
+			)
+		}
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+		case <-ticks:
+		}
+
+		if m.isDestroyed() {
+			return
+		}
+
+		view := SplitView{Name: feature, ChangeNumber: RemovedChangeNumber}
+		if split := m.splitStorage.Split(feature); split != nil {
+			view = *m.newSplitView(split)
+		}
+		if view.ChangeNumber == lastChangeNumber {
+			continue
+		}
+		lastChangeNumber = view.ChangeNumber
+
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- view
+	}
+}
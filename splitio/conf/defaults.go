@@ -10,6 +10,8 @@ const (
 	defaultSegmentWorkers          = 10
 	defaultImpressionSyncOptimized = 300
 	defaultImpressionSyncDebug     = 60
+	defaultUniqueKeysSync          = 900
+	defaultUniqueKeysCacheSize     = 30000
 )
 
 const (
@@ -19,4 +21,5 @@ const (
 	minImpressionSyncOptimized = 60
 	minEventSync               = 1
 	minTelemetrySync           = 30
+	minUniqueKeysSync          = 30
 )
@@ -0,0 +1,469 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/splitio/go-toolkit/logging"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindInt64
+	kindBool
+	kindStringList
+	kindLogLevel
+	kindDuration
+)
+
+// configKey maps a key of the configuration schema to a field of SplitSdkConfig
+type configKey struct {
+	path  string
+	kind  valueKind
+	field func(cfg *SplitSdkConfig) interface{}
+}
+
+// configKeys is the schema read by FromFile and FromEnv. Keep the table in the FromFile doc in sync with it
+var configKeys = []configKey{
+	{"operationMode", kindString, func(c *SplitSdkConfig) interface{} { return &c.OperationMode }},
+	{"instanceName", kindString, func(c *SplitSdkConfig) interface{} { return &c.InstanceName }},
+	{"ipAddress", kindString, func(c *SplitSdkConfig) interface{} { return &c.IPAddress }},
+	{"ipAddressesEnabled", kindBool, func(c *SplitSdkConfig) interface{} { return &c.IPAddressesEnabled }},
+	{"blockUntilReady", kindInt, func(c *SplitSdkConfig) interface{} { return &c.BlockUntilReady }},
+	{"blockUntilReadyTimeout", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.BlockUntilReadyTimeout }},
+	{"splitFile", kindString, func(c *SplitSdkConfig) interface{} { return &c.SplitFile }},
+	{"splitFiles", kindStringList, func(c *SplitSdkConfig) interface{} { return &c.SplitFiles }},
+	{"segmentDirectory", kindString, func(c *SplitSdkConfig) interface{} { return &c.SegmentDirectory }},
+	{"recordFile", kindString, func(c *SplitSdkConfig) interface{} { return &c.RecordFile }},
+	{"labelsEnabled", kindBool, func(c *SplitSdkConfig) interface{} { return &c.LabelsEnabled }},
+	{"splitSyncProxyURL", kindString, func(c *SplitSdkConfig) interface{} { return &c.SplitSyncProxyURL }},
+	{"impressionsMode", kindString, func(c *SplitSdkConfig) interface{} { return &c.ImpressionsMode }},
+	{"logger.logLevel", kindLogLevel, func(c *SplitSdkConfig) interface{} { return &c.LoggerConfig.LogLevel }},
+	{"logger.prefix", kindString, func(c *SplitSdkConfig) interface{} { return &c.LoggerConfig.Prefix }},
+	{"taskPeriods.splitSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.SplitSync }},
+	{"taskPeriods.segmentSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.SegmentSync }},
+	{"taskPeriods.impressionSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.ImpressionSync }},
+	{"taskPeriods.gaugeSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.GaugeSync }},
+	{"taskPeriods.counterSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.CounterSync }},
+	{"taskPeriods.latencySync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.LatencySync }},
+	{"taskPeriods.eventsSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.EventsSync }},
+	{"taskPeriods.uniqueKeysSync", kindInt, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.UniqueKeysSync }},
+	{"taskPeriods.splitSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.SplitSyncPeriod }},
+	{"taskPeriods.segmentSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.SegmentSyncPeriod }},
+	{"taskPeriods.impressionSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.ImpressionSyncPeriod }},
+	{"taskPeriods.gaugeSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.GaugeSyncPeriod }},
+	{"taskPeriods.counterSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.CounterSyncPeriod }},
+	{"taskPeriods.latencySyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.LatencySyncPeriod }},
+	{"taskPeriods.eventsSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.EventsSyncPeriod }},
+	{"taskPeriods.uniqueKeysSyncPeriod", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.TaskPeriods.UniqueKeysSyncPeriod }},
+	{"advanced.httpTimeout", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.HTTPTimeout }},
+	{"advanced.httpTimeoutDuration", kindDuration, func(c *SplitSdkConfig) interface{} { return &c.Advanced.HTTPTimeoutDuration }},
+	{"advanced.segmentQueueSize", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.SegmentQueueSize }},
+	{"advanced.segmentWorkers", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.SegmentWorkers }},
+	{"advanced.authServiceURL", kindString, func(c *SplitSdkConfig) interface{} { return &c.Advanced.AuthServiceURL }},
+	{"advanced.sdkURL", kindString, func(c *SplitSdkConfig) interface{} { return &c.Advanced.SdkURL }},
+	{"advanced.eventsURL", kindString, func(c *SplitSdkConfig) interface{} { return &c.Advanced.EventsURL }},
+	{"advanced.streamingServiceURL", kindString, func(c *SplitSdkConfig) interface{} { return &c.Advanced.StreamingServiceURL }},
+	{"advanced.streamingEnabled", kindBool, func(c *SplitSdkConfig) interface{} { return &c.Advanced.StreamingEnabled }},
+	{"advanced.eventsBulkSize", kindInt64, func(c *SplitSdkConfig) interface{} { return &c.Advanced.EventsBulkSize }},
+	{"advanced.eventsQueueSize", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.EventsQueueSize }},
+	{"advanced.impressionsBulkSize", kindInt64, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionsBulkSize }},
+	{"advanced.impressionsQueueSize", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionsQueueSize }},
+	{"advanced.impressionListenerQueueSize", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionListenerQueueSize }},
+	{"advanced.impressionListenerWorkers", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionListenerWorkers }},
+	{"advanced.impressionListenerOverflowPolicy", kindString, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionListenerOverflowPolicy }},
+	{"advanced.impressionListenerBatchSize", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionListenerBatchSize }},
+	{"advanced.impressionListenerFlushInterval", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionListenerFlushInterval }},
+	{"advanced.uniqueKeysCacheSize", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Advanced.UniqueKeysCacheSize }},
+	{"advanced.impressionsDisabledFeatures", kindStringList, func(c *SplitSdkConfig) interface{} { return &c.Advanced.ImpressionsDisabledFeatures }},
+	{"redis.host", kindString, func(c *SplitSdkConfig) interface{} { return &c.Redis.Host }},
+	{"redis.port", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Redis.Port }},
+	{"redis.database", kindInt, func(c *SplitSdkConfig) interface{} { return &c.Redis.Database }},
+	{"redis.password", kindString, func(c *SplitSdkConfig) interface{} { return &c.Redis.Password }},
+	{"redis.prefix", kindString, func(c *SplitSdkConfig) interface{} { return &c.Redis.Prefix }},
+}
+
+var logLevels = map[string]int{
+	"NONE":    logging.LevelNone,
+	"ERROR":   logging.LevelError,
+	"WARNING": logging.LevelWarning,
+	"INFO":    logging.LevelInfo,
+	"DEBUG":   logging.LevelDebug,
+	"VERBOSE": logging.LevelVerbose,
+}
+
+// FromEnv returns the default config overridden by environment variables. Each key of the schema documented in
+// FromFile is read from the variable named after it, prefixed by prefix (ie: with prefix "SPLIT_",
+// advanced.httpTimeout is read from SPLIT_ADVANCED_HTTP_TIMEOUT). Variable names are the keys in upper snake case:
+// dots and the boundaries between camel case words become underscores, keeping acronyms together (advanced.sdkURL
+// is ADVANCED_SDK_URL). Lists are comma separated. Every invalid value and every unknown variable starting with a
+// non-empty prefix is reported in a *ValidationError naming the offending variables. The config is normalized when
+// the factory is created
+func FromEnv(prefix string) (*SplitSdkConfig, error) {
+	cfg := Default()
+	errs := &ValidationError{}
+	known := make(map[string]struct{}, len(configKeys))
+	for _, key := range configKeys {
+		name := prefix + envName(key.path)
+		known[name] = struct{}{}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		value, err := parseString(key.kind, raw)
+		if err != nil {
+			errs.add(name, "%s", err.Error())
+			continue
+		}
+		setValue(key.field(cfg), value)
+	}
+
+	if prefix != "" {
+		unknown := make([]string, 0)
+		for _, variable := range os.Environ() {
+			name := strings.SplitN(variable, "=", 2)[0]
+			if _, ok := known[name]; !ok && strings.HasPrefix(name, prefix) {
+				unknown = append(unknown, name)
+			}
+		}
+		addUnknown(errs, "", unknown, "variable")
+	}
+
+	if err := errs.orNil(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// addUnknown reports every unknown key or variable as a single problem, prefixing their names by source
+func addUnknown(errs *ValidationError, source string, unknown []string, kind string) {
+	if len(unknown) == 0 {
+		return
+	}
+	sort.Strings(unknown)
+	if len(unknown) == 1 {
+		errs.add(source+unknown[0], "unknown %s", kind)
+		return
+	}
+	errs.add(source+strings.Join(unknown, ", "), "unknown %ss", kind)
+}
+
+// FromFile returns the default config overridden by the values of a YAML (.yaml or .yml) or JSON (.json) file.
+// Keys of nested sections are written as nested mappings (ie: taskPeriods.splitSync is the splitSync key of the
+// taskPeriods mapping). Every invalid value and every unknown key is reported in a *ValidationError naming the
+// offending keys. The config is normalized when the factory is created. Log levels are one of NONE, ERROR, WARNING,
+// INFO, DEBUG or VERBOSE, and durations use the time.ParseDuration syntax (ie: "500ms", "1m30s"). The keys, along
+// with the environment variables read by FromEnv without the prefix, are:
+//
+//	Key                                        Type             Environment variable
+//	operationMode                              string           OPERATION_MODE
+//	instanceName                               string           INSTANCE_NAME
+//	ipAddress                                  string           IP_ADDRESS
+//	ipAddressesEnabled                         boolean          IP_ADDRESSES_ENABLED
+//	blockUntilReady                            integer          BLOCK_UNTIL_READY
+//	blockUntilReadyTimeout                     duration         BLOCK_UNTIL_READY_TIMEOUT
+//	splitFile                                  string           SPLIT_FILE
+//	splitFiles                                 list of strings  SPLIT_FILES
+//	segmentDirectory                           string           SEGMENT_DIRECTORY
+//	recordFile                                 string           RECORD_FILE
+//	labelsEnabled                              boolean          LABELS_ENABLED
+//	splitSyncProxyURL                          string           SPLIT_SYNC_PROXY_URL
+//	impressionsMode                            string           IMPRESSIONS_MODE
+//	logger.logLevel                            log level        LOGGER_LOG_LEVEL
+//	logger.prefix                              string           LOGGER_PREFIX
+//	taskPeriods.splitSync                      integer          TASK_PERIODS_SPLIT_SYNC
+//	taskPeriods.segmentSync                    integer          TASK_PERIODS_SEGMENT_SYNC
+//	taskPeriods.impressionSync                 integer          TASK_PERIODS_IMPRESSION_SYNC
+//	taskPeriods.gaugeSync                      integer          TASK_PERIODS_GAUGE_SYNC
+//	taskPeriods.counterSync                    integer          TASK_PERIODS_COUNTER_SYNC
+//	taskPeriods.latencySync                    integer          TASK_PERIODS_LATENCY_SYNC
+//	taskPeriods.eventsSync                     integer          TASK_PERIODS_EVENTS_SYNC
+//	taskPeriods.uniqueKeysSync                 integer          TASK_PERIODS_UNIQUE_KEYS_SYNC
+//	taskPeriods.splitSyncPeriod                duration         TASK_PERIODS_SPLIT_SYNC_PERIOD
+//	taskPeriods.segmentSyncPeriod              duration         TASK_PERIODS_SEGMENT_SYNC_PERIOD
+//	taskPeriods.impressionSyncPeriod           duration         TASK_PERIODS_IMPRESSION_SYNC_PERIOD
+//	taskPeriods.gaugeSyncPeriod                duration         TASK_PERIODS_GAUGE_SYNC_PERIOD
+//	taskPeriods.counterSyncPeriod              duration         TASK_PERIODS_COUNTER_SYNC_PERIOD
+//	taskPeriods.latencySyncPeriod              duration         TASK_PERIODS_LATENCY_SYNC_PERIOD
+//	taskPeriods.eventsSyncPeriod               duration         TASK_PERIODS_EVENTS_SYNC_PERIOD
+//	taskPeriods.uniqueKeysSyncPeriod           duration         TASK_PERIODS_UNIQUE_KEYS_SYNC_PERIOD
+//	advanced.httpTimeout                       integer          ADVANCED_HTTP_TIMEOUT
+//	advanced.httpTimeoutDuration               duration         ADVANCED_HTTP_TIMEOUT_DURATION
+//	advanced.segmentQueueSize                  integer          ADVANCED_SEGMENT_QUEUE_SIZE
+//	advanced.segmentWorkers                    integer          ADVANCED_SEGMENT_WORKERS
+//	advanced.authServiceURL                    string           ADVANCED_AUTH_SERVICE_URL
+//	advanced.sdkURL                            string           ADVANCED_SDK_URL
+//	advanced.eventsURL                         string           ADVANCED_EVENTS_URL
+//	advanced.streamingServiceURL               string           ADVANCED_STREAMING_SERVICE_URL
+//	advanced.streamingEnabled                  boolean          ADVANCED_STREAMING_ENABLED
+//	advanced.eventsBulkSize                    integer          ADVANCED_EVENTS_BULK_SIZE
+//	advanced.eventsQueueSize                   integer          ADVANCED_EVENTS_QUEUE_SIZE
+//	advanced.impressionsBulkSize               integer          ADVANCED_IMPRESSIONS_BULK_SIZE
+//	advanced.impressionsQueueSize              integer          ADVANCED_IMPRESSIONS_QUEUE_SIZE
+//	advanced.impressionListenerQueueSize       integer          ADVANCED_IMPRESSION_LISTENER_QUEUE_SIZE
+//	advanced.impressionListenerWorkers         integer          ADVANCED_IMPRESSION_LISTENER_WORKERS
+//	advanced.impressionListenerOverflowPolicy  string           ADVANCED_IMPRESSION_LISTENER_OVERFLOW_POLICY
+//	advanced.impressionListenerBatchSize       integer          ADVANCED_IMPRESSION_LISTENER_BATCH_SIZE
+//	advanced.impressionListenerFlushInterval   integer          ADVANCED_IMPRESSION_LISTENER_FLUSH_INTERVAL
+//	advanced.uniqueKeysCacheSize               integer          ADVANCED_UNIQUE_KEYS_CACHE_SIZE
+//	advanced.impressionsDisabledFeatures       list of strings  ADVANCED_IMPRESSIONS_DISABLED_FEATURES
+//	redis.host                                 string           REDIS_HOST
+//	redis.port                                 integer          REDIS_PORT
+//	redis.database                             integer          REDIS_DATABASE
+//	redis.password                             string           REDIS_PASSWORD
+//	redis.prefix                               string           REDIS_PREFIX
+func FromFile(path string) (*SplitSdkConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root interface{}
+	switch extension := strings.ToLower(filepath.Ext(path)); extension {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&root)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &root)
+	default:
+		return nil, fmt.Errorf("%s: unsupported extension %s. Supported extensions are .json, .yaml and .yml", path, extension)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	values := make(map[string]interface{})
+	if root != nil {
+		err = flatten("", root, values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+	}
+
+	cfg := Default()
+	errs := &ValidationError{}
+	for _, key := range configKeys {
+		raw, ok := values[key.path]
+		if !ok {
+			continue
+		}
+		delete(values, key.path)
+		value, err := parseValue(key.kind, raw)
+		if err != nil {
+			errs.add(path+": "+key.path, "%s", err.Error())
+			continue
+		}
+		setValue(key.field(cfg), value)
+	}
+
+	unknown := make([]string, 0, len(values))
+	for name := range values {
+		unknown = append(unknown, name)
+	}
+	addUnknown(errs, path+": ", unknown, "key")
+
+	if err := errs.orNil(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// flatten collects the values of nested mappings under their dotted keys. Mappings holding a known key are kept
+// as values so they are reported as having the wrong type
+func flatten(prefix string, node interface{}, values map[string]interface{}) error {
+	var entries map[string]interface{}
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		entries = typed
+	case map[interface{}]interface{}:
+		entries = make(map[string]interface{}, len(typed))
+		for name, value := range typed {
+			entries[fmt.Sprint(name)] = value
+		}
+	default:
+		if prefix == "" {
+			return fmt.Errorf("the config must be a mapping")
+		}
+		values[prefix] = node
+		return nil
+	}
+
+	if prefix != "" && isKey(prefix) {
+		values[prefix] = node
+		return nil
+	}
+	for name, value := range entries {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		err := flatten(path, value, values)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isKey(path string) bool {
+	for _, key := range configKeys {
+		if key.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// parseString converts the value of an environment variable
+func parseString(kind valueKind, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	switch kind {
+	case kindInt, kindInt64:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer. Actual is: %s", raw)
+		}
+		return value, nil
+	case kindBool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean. Actual is: %s", raw)
+		}
+		return value, nil
+	case kindStringList:
+		list := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	case kindLogLevel:
+		return parseLogLevel(raw)
+	case kindDuration:
+		return parseDuration(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// parseValue converts a value decoded from a file
+func parseValue(kind valueKind, raw interface{}) (interface{}, error) {
+	switch kind {
+	case kindInt, kindInt64:
+		switch typed := raw.(type) {
+		case int:
+			return int64(typed), nil
+		case int64:
+			return typed, nil
+		case json.Number:
+			if value, err := typed.Int64(); err == nil {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("must be an integer. Actual is: %v", raw)
+	case kindBool:
+		if value, ok := raw.(bool); ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("must be a boolean. Actual is: %v", raw)
+	case kindStringList:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be a list of strings. Actual is: %v", raw)
+		}
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			value, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of strings. Actual is: %v", raw)
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case kindLogLevel:
+		if value, ok := raw.(string); ok {
+			return parseLogLevel(value)
+		}
+		return nil, fmt.Errorf("must be one of NONE, ERROR, WARNING, INFO, DEBUG or VERBOSE. Actual is: %v", raw)
+	case kindDuration:
+		if value, ok := raw.(string); ok {
+			return parseDuration(value)
+		}
+		return nil, fmt.Errorf("must be a duration. Actual is: %v", raw)
+	default:
+		if value, ok := raw.(string); ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("must be a string. Actual is: %v", raw)
+	}
+}
+
+func parseLogLevel(raw string) (interface{}, error) {
+	level, ok := logLevels[strings.ToUpper(strings.TrimSpace(raw))]
+	if !ok {
+		return nil, fmt.Errorf("must be one of NONE, ERROR, WARNING, INFO, DEBUG or VERBOSE. Actual is: %s", raw)
+	}
+	return int64(level), nil
+}
+
+func parseDuration(raw string) (interface{}, error) {
+	value, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("must be a duration. Actual is: %s", raw)
+	}
+	return value, nil
+}
+
+func setValue(field interface{}, value interface{}) {
+	switch typed := field.(type) {
+	case *string:
+		*typed = value.(string)
+	case *int:
+		*typed = int(value.(int64))
+	case *int64:
+		*typed = value.(int64)
+	case *bool:
+		*typed = value.(bool)
+	case *time.Duration:
+		*typed = value.(time.Duration)
+	case *[]string:
+		*typed = value.([]string)
+	}
+}
+
+// envName converts a dotted camel case key into an upper snake case environment variable name, keeping acronyms
+// together (ie: advanced.sdkURL is ADVANCED_SDK_URL)
+func envName(path string) string {
+	var name bytes.Buffer
+	runes := []rune(path)
+	for index, r := range runes {
+		if r == '.' {
+			name.WriteRune('_')
+			continue
+		}
+		if index > 0 && unicode.IsUpper(r) {
+			previous := runes[index-1]
+			nextIsLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				name.WriteRune('_')
+			}
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
@@ -0,0 +1,156 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func writeConfigFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Error("Couldn't write config file:", err)
+	}
+	return path
+}
+
+func TestFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_conf")
+	if err != nil {
+		t.Error("Couldn't create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	yamlPath := writeConfigFile(t, dir, "config.yaml", `
+operationMode: redis-consumer
+labelsEnabled: false
+logger:
+  logLevel: debug
+taskPeriods:
+  splitSync: 30
+advanced:
+  eventsBulkSize: 100
+  impressionsDisabledFeatures: ["internal_*"]
+redis:
+  host: redis.local
+  port: 6380
+`)
+	cfg, err := FromFile(yamlPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cfg.OperationMode != RedisConsumer || cfg.LabelsEnabled || cfg.LoggerConfig.LogLevel != logging.LevelDebug {
+		t.Error("Top level values should be read")
+	}
+	if cfg.TaskPeriods.SplitSync != 30 || cfg.TaskPeriods.SegmentSync != defaultTaskPeriod {
+		t.Error("Values should be merged over the defaults")
+	}
+	if cfg.Advanced.EventsBulkSize != 100 || len(cfg.Advanced.ImpressionsDisabledFeatures) != 1 || cfg.Redis.Host != "redis.local" || cfg.Redis.Port != 6380 {
+		t.Error("Nested values should be read")
+	}
+
+	jsonPath := writeConfigFile(t, dir, "config.json", `{"blockUntilReady": 10, "splitFiles": ["a.yaml", "b.yaml"], "advanced": {"streamingEnabled": false}}`)
+	cfg, err = FromFile(jsonPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cfg.BlockUntilReady != 10 || len(cfg.SplitFiles) != 2 || cfg.Advanced.StreamingEnabled {
+		t.Error("JSON values should be read")
+	}
+
+	errorsByContent := map[string]string{
+		`{"taskPeriods": {"splitSync": "often"}}`:        jsonPath + ": taskPeriods.splitSync: must be an integer. Actual is: often",
+		`{"advanced": {"httpTimeout": 1.5}}`:             jsonPath + ": advanced.httpTimeout: must be an integer. Actual is: 1.5",
+		`{"redis": {"hots": "localhost"}}`:               jsonPath + ": redis.hots: unknown key",
+		`{"redis": {"prot": 6379, "hots": "localhost"}}`: jsonPath + ": redis.hots, redis.prot: unknown keys",
+		`{"labelsEnabled": {"value": true}}`:             jsonPath + ": labelsEnabled: must be a boolean. Actual is: map[value:true]",
+		`{"logger": {"logLevel": "loud"}}`:               jsonPath + ": logger.logLevel: must be one of NONE, ERROR, WARNING, INFO, DEBUG or VERBOSE. Actual is: loud",
+		`["operationMode"]`:                              jsonPath + ": the config must be a mapping",
+	}
+	for content, expected := range errorsByContent {
+		writeConfigFile(t, dir, "config.json", content)
+		_, err = FromFile(jsonPath)
+		if err == nil || err.Error() != expected {
+			t.Error("Unexpected error for", content, "Actual:", err)
+		}
+	}
+
+	writeConfigFile(t, dir, "config.json", `{"blockUntilReady": "soon", "labelsEnabled": "yes", "redis": {"hots": "localhost"}}`)
+	_, err = FromFile(jsonPath)
+	validationErr, ok := err.(*ValidationError)
+	if !ok || strings.Join(validationErr.Fields(), "|") != jsonPath+": blockUntilReady|"+jsonPath+": labelsEnabled|"+jsonPath+": redis.hots" {
+		t.Error("Every invalid value and unknown key should be reported. Actual:", err)
+	}
+
+	if _, err = FromFile(writeConfigFile(t, dir, "config.toml", "")); err == nil {
+		t.Error("Unsupported extensions should fail")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	variables := map[string]string{
+		"TEST_SPLIT_OPERATION_MODE":                         "localhost",
+		"TEST_SPLIT_IP_ADDRESSES_ENABLED":                   "false",
+		"TEST_SPLIT_TASK_PERIODS_SEGMENT_SYNC":              "120",
+		"TEST_SPLIT_ADVANCED_SDK_URL":                       "https://sdk.local",
+		"TEST_SPLIT_ADVANCED_IMPRESSIONS_DISABLED_FEATURES": "a, b,",
+		"TEST_SPLIT_SPLIT_SYNC_PROXY_URL":                   "https://proxy.local",
+		"TEST_SPLIT_TASK_PERIODS_SPLIT_SYNC_PERIOD":         "250ms",
+	}
+	for name, value := range variables {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	cfg, err := FromEnv("TEST_SPLIT_")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cfg.OperationMode != Localhost || cfg.IPAddressesEnabled || cfg.TaskPeriods.SegmentSync != 120 {
+		t.Error("Values should be read from the environment")
+	}
+	if cfg.Advanced.SdkURL != "https://sdk.local" || cfg.SplitSyncProxyURL != "https://proxy.local" {
+		t.Error("Acronyms should be kept together in variable names")
+	}
+	if len(cfg.Advanced.ImpressionsDisabledFeatures) != 2 || cfg.Advanced.ImpressionsDisabledFeatures[1] != "b" {
+		t.Error("Lists should be comma separated. Actual:", cfg.Advanced.ImpressionsDisabledFeatures)
+	}
+	if cfg.TaskPeriods.SplitSync != defaultTaskPeriod {
+		t.Error("Values should be merged over the defaults")
+	}
+	if cfg.TaskPeriods.SplitSyncPeriod != 250*time.Millisecond {
+		t.Error("Durations should be parsed. Actual:", cfg.TaskPeriods.SplitSyncPeriod)
+	}
+
+	os.Setenv("TEST_SPLIT_REDIS_PORT", "redis")
+	defer os.Unsetenv("TEST_SPLIT_REDIS_PORT")
+	_, err = FromEnv("TEST_SPLIT_")
+	if err == nil || err.Error() != "TEST_SPLIT_REDIS_PORT: must be an integer. Actual is: redis" {
+		t.Error("Errors should name the variable. Actual:", err)
+	}
+
+	os.Setenv("TEST_SPLIT_REDIS_DATABASE", "first")
+	os.Setenv("TEST_SPLIT_REDIS_HOTS", "localhost")
+	os.Setenv("TEST_SPLIT_LABELS", "true")
+	defer os.Unsetenv("TEST_SPLIT_REDIS_DATABASE")
+	defer os.Unsetenv("TEST_SPLIT_REDIS_HOTS")
+	defer os.Unsetenv("TEST_SPLIT_LABELS")
+	_, err = FromEnv("TEST_SPLIT_")
+	validationErr, ok := err.(*ValidationError)
+	if !ok || strings.Join(validationErr.Fields(), "|") != "TEST_SPLIT_REDIS_PORT|TEST_SPLIT_REDIS_DATABASE|TEST_SPLIT_LABELS, TEST_SPLIT_REDIS_HOTS" {
+		t.Error("Every invalid value and unknown variable should be reported. Actual:", err)
+	}
+	if err != nil && !strings.HasSuffix(err.Error(), "; TEST_SPLIT_LABELS, TEST_SPLIT_REDIS_HOTS: unknown variables") {
+		t.Error("Unknown variables should be reported together. Actual:", err)
+	}
+}
@@ -2,13 +2,15 @@
 package conf
 
 import (
-	"errors"
 	"fmt"
-	"math"
+	"io"
+	"io/fs"
 	"os/user"
 	"path"
 	"strings"
+	"time"
 
+	eventlistener "github.com/splitio/go-client/splitio/eventListener"
 	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
 	"github.com/splitio/go-split-commons/conf"
 	"github.com/splitio/go-toolkit/datastructures/set"
@@ -25,6 +27,9 @@ const (
 	InMemoryStandAlone = "inmemory-standalone"
 )
 
+// ImpressionsModeNone records no impressions, only their counts and the unique keys evaluated for each feature
+const ImpressionsModeNone = "none"
+
 // SplitSdkConfig struct ...
 // struct used to setup a Split.io SDK client.
 //
@@ -32,62 +37,126 @@ const (
 // - OperationMode (Required) Must be one of ["inmemory-standalone", "redis-consumer"]
 // - InstanceName (Optional) Name to be used when submitting metrics & impressions to split servers
 // - IPAddress (Optional) Address to be used when submitting metrics & impressions to split servers
-// - BlockUntilReady (Optional) How much to wait until the sdk is ready
-// - SplitFile (Optional) File with splits to use when running in localhost mode
+// - BlockUntilReady (Optional) How much to wait, in seconds, until the sdk is ready when calling
+// SplitFactory.WaitUntilReady. Deprecated: use BlockUntilReadyTimeout
+// - BlockUntilReadyTimeout (Optional) How much to wait until the sdk is ready when calling SplitFactory.WaitUntilReady.
+// Takes precedence over BlockUntilReady
+// - SplitFile (Optional) File with splits to use when running in localhost mode. JSON files are read either as splitChanges responses
+// or as snapshots exported by SplitManager. YAML files with a top level 'splits' mapping support attribute conditions and weighted treatments
+// - SplitFiles (Optional) Ordered list of files with splits, in any of the supported formats, to use instead of
+// SplitFile. Later files take precedence per split and condition, so shared defaults can be overridden by local files
+// - SegmentDirectory (Optional) Directory with segmentChanges JSON files to use when running in localhost mode
+// - SplitFS (Optional) File system, such as an embed.FS, SplitFile and SegmentDirectory are read from instead of disk
+// - SplitData (Optional) Content of the split file, in any of the supported formats, to use instead of SplitFile
+// - SplitReader (Optional) Reader with the content of the split file, read once when the factory is created. It
+// can't be combined with SplitData
+// - RecordFile (Optional) File where impressions and events are appended as JSON lines when running in localhost mode
 // - LabelsEnabled (Optional) Can be used to disable labels if the user does not want to send that info to split servers.
 // - Logger: (Optional) Custom logger complying with logging.LoggerInterface
 // - LoggerConfig: (Optional) Options to setup the sdk's own logger
-// - TaskPeriods: (Optional) How often should each task run
+// - TaskPeriods: (Optional) How often should each task run, either in seconds or, taking precedence, as durations
 // - Redis: (Required for "redis-consumer". Sets up Redis config
 // - Advanced: (Optional) Sets up various advanced options for the sdk
-// - ImpressionsMode (Optional) Flag for enabling local impressions dedupe - Possible values <'optimized'|'debug'>
+// - ImpressionsMode (Optional) Flag for enabling local impressions dedupe - Possible values <'optimized'|'debug'|'none'>
 type SplitSdkConfig struct {
-	OperationMode      string
-	InstanceName       string
-	IPAddress          string
-	IPAddressesEnabled bool
-	BlockUntilReady    int
-	SplitFile          string
-	LabelsEnabled      bool
-	SplitSyncProxyURL  string
-	Logger             logging.LoggerInterface
-	LoggerConfig       logging.LoggerOptions
-	TaskPeriods        TaskPeriods
-	Advanced           AdvancedConfig
-	Redis              conf.RedisConfig
-	ImpressionsMode    string
+	OperationMode          string
+	InstanceName           string
+	IPAddress              string
+	IPAddressesEnabled     bool
+	BlockUntilReady        int
+	BlockUntilReadyTimeout time.Duration
+	SplitFile              string
+	SplitFiles             []string
+	SegmentDirectory       string
+	SplitFS                fs.FS
+	SplitData              []byte
+	SplitReader            io.Reader
+	RecordFile             string
+	LabelsEnabled          bool
+	SplitSyncProxyURL      string
+	Logger                 logging.LoggerInterface
+	LoggerConfig           logging.LoggerOptions
+	TaskPeriods            TaskPeriods
+	Advanced               AdvancedConfig
+	Redis                  conf.RedisConfig
+	ImpressionsMode        string
 }
 
-// TaskPeriods struct is used to configure the period for each synchronization task
+// TaskPeriods struct is used to configure the period for each synchronization task. The int fields are in seconds
+// and deprecated: every one has a time.Duration alternative, named after it with a Period suffix, which takes
+// precedence when set. Both are validated against the same minimums, except in localhost mode where durations only
+// need to be positive. The synchronization tasks only support whole seconds, so Normalize rounds the durations set
+// up to seconds into the int fields (ie: 1500ms runs every 2 seconds). The only sub-second period honored is
+// SplitSyncPeriod in localhost mode, which also sets how often the split files are checked for changes
 type TaskPeriods struct {
-	SplitSync      int
-	SegmentSync    int
-	ImpressionSync int
-	GaugeSync      int
-	CounterSync    int
-	LatencySync    int
-	EventsSync     int
+	SplitSync            int
+	SegmentSync          int
+	ImpressionSync       int
+	GaugeSync            int
+	CounterSync          int
+	LatencySync          int
+	EventsSync           int
+	UniqueKeysSync       int
+	SplitSyncPeriod      time.Duration
+	SegmentSyncPeriod    time.Duration
+	ImpressionSyncPeriod time.Duration
+	GaugeSyncPeriod      time.Duration
+	CounterSyncPeriod    time.Duration
+	LatencySyncPeriod    time.Duration
+	EventsSyncPeriod     time.Duration
+	UniqueKeysSyncPeriod time.Duration
 }
 
 // AdvancedConfig exposes more configurable parameters that can be used to further tailor the sdk to the user's needs
 // - ImpressionListener - struct that will be notified each time an impression bulk is ready
-// - HTTPTimeout - Timeout for HTTP requests when doing synchronization
+// - HTTPTimeout - Timeout, in seconds, for HTTP requests when doing synchronization. Deprecated: use HTTPTimeoutDuration
+// - HTTPTimeoutDuration - Timeout for HTTP requests when doing synchronization. Takes precedence over HTTPTimeout
 // - SegmentQueueSize - How many segments can be queued for updating (should be >= # segments the user has)
 // - SegmentWorkers - How many workers will be used when performing segments sync.
+// - ImpressionListeners - additional listeners, each one notified only with the impressions matching its filter
+// - ImpressionListenerQueueSize - How many impressions can wait for the listener. When 0 the listener is called synchronously
+// - ImpressionListenerWorkers - How many goroutines will deliver queued impressions to the listener
+// - ImpressionListenerOverflowPolicy - What to do when the listener queue is full <'drop_oldest'|'drop_newest'|'block'>
+// - ImpressionListenerBatchSize - How many impressions are handed at once to listeners implementing BatchImpressionListener (100 when 0)
+// - ImpressionListenerFlushInterval - How often (in seconds) buffered impressions are handed to a BatchImpressionListener (5 when 0)
+// - EventListener - struct that will be notified each time an event is tracked
+// - ImpressionsDisabledFeatures - Feature names or patterns (path.Match syntax) whose impressions are counted but not logged.
+// Not supported in redis-consumer mode, where impression counts are not tracked. In localhost mode they're not recorded
+// - UniqueKeysCacheSize - How many unique keys can be held before being flushed when running in "none" impressions mode
 type AdvancedConfig struct {
-	ImpressionListener   impressionlistener.ImpressionListener
-	HTTPTimeout          int
-	SegmentQueueSize     int
-	SegmentWorkers       int
-	AuthServiceURL       string
-	SdkURL               string
-	EventsURL            string
-	StreamingServiceURL  string
-	EventsBulkSize       int64
-	EventsQueueSize      int
-	ImpressionsQueueSize int
-	ImpressionsBulkSize  int64
-	StreamingEnabled     bool
+	ImpressionListener               impressionlistener.ImpressionListener
+	ImpressionListeners              []impressionlistener.FilteredListener
+	ImpressionListenerQueueSize      int
+	ImpressionListenerWorkers        int
+	ImpressionListenerOverflowPolicy string
+	ImpressionListenerBatchSize      int
+	ImpressionListenerFlushInterval  int
+	EventListener                    eventlistener.EventListener
+	HTTPTimeout                      int
+	HTTPTimeoutDuration              time.Duration
+	SegmentQueueSize                 int
+	SegmentWorkers                   int
+	AuthServiceURL                   string
+	SdkURL                           string
+	EventsURL                        string
+	StreamingServiceURL              string
+	EventsBulkSize                   int64
+	EventsQueueSize                  int
+	ImpressionsQueueSize             int
+	ImpressionsBulkSize              int64
+	StreamingEnabled                 bool
+	UniqueKeysCacheSize              int
+	ImpressionsDisabledFeatures      []string
+}
+
+// IsImpressionsDisabled returns true if impressions for the feature should be counted but not logged
+func (a *AdvancedConfig) IsImpressionsDisabled(feature string) bool {
+	for _, pattern := range a.ImpressionsDisabledFeatures {
+		if matched, _ := path.Match(pattern, feature); matched {
+			return true
+		}
+	}
+	return false
 }
 
 // Default returns a config struct with all the default values
@@ -133,104 +202,227 @@ func Default() *SplitSdkConfig {
 			SegmentSync:    defaultTaskPeriod,
 			SplitSync:      defaultTaskPeriod,
 			EventsSync:     defaultTaskPeriod,
+			UniqueKeysSync: defaultUniqueKeysSync,
 		},
 		Advanced: AdvancedConfig{
-			AuthServiceURL:       "",
-			EventsURL:            "",
-			SdkURL:               "",
-			StreamingServiceURL:  "",
-			HTTPTimeout:          0,
-			ImpressionListener:   nil,
-			SegmentQueueSize:     500,
-			SegmentWorkers:       10,
-			EventsBulkSize:       5000,
-			EventsQueueSize:      10000,
-			ImpressionsQueueSize: 10000,
-			ImpressionsBulkSize:  5000,
-			StreamingEnabled:     true,
+			AuthServiceURL:                   "",
+			EventsURL:                        "",
+			SdkURL:                           "",
+			StreamingServiceURL:              "",
+			HTTPTimeout:                      0,
+			ImpressionListener:               nil,
+			ImpressionListenerQueueSize:      0,
+			ImpressionListenerWorkers:        1,
+			ImpressionListenerOverflowPolicy: impressionlistener.OverflowPolicyDropNewest,
+			ImpressionListenerBatchSize:      100,
+			ImpressionListenerFlushInterval:  5,
+			SegmentQueueSize:                 500,
+			SegmentWorkers:                   10,
+			EventsBulkSize:                   5000,
+			EventsQueueSize:                  10000,
+			ImpressionsQueueSize:             10000,
+			ImpressionsBulkSize:              5000,
+			StreamingEnabled:                 true,
+			UniqueKeysCacheSize:              defaultUniqueKeysCacheSize,
 		},
 	}
 }
 
-func checkImpressionSync(cfg *SplitSdkConfig) error {
-	if cfg.TaskPeriods.ImpressionSync == 0 {
-		cfg.TaskPeriods.ImpressionSync = defaultImpressionSyncOptimized
-	} else {
-		if cfg.TaskPeriods.ImpressionSync < minImpressionSyncOptimized {
-			return fmt.Errorf("ImpressionSync must be >= %d. Actual is: %d", minImpressionSyncOptimized, cfg.TaskPeriods.ImpressionSync)
+// checkPeriod validates a task period set either in seconds or, taking precedence, as a duration, which is rounded
+// up into seconds. Durations only need to be positive in localhost mode, where there are no servers to protect
+func checkPeriod(cfg *SplitSdkConfig, errs *ValidationError, field string, seconds *int, duration *time.Duration, min int) {
+	if *duration == 0 {
+		if *seconds < min {
+			errs.add(field, "must be >= %d. Actual is: %d", min, *seconds)
 		}
-		cfg.TaskPeriods.ImpressionSync = int(math.Max(float64(minImpressionSyncOptimized), float64(cfg.TaskPeriods.ImpressionSync)))
+		return
 	}
-	return nil
+
+	minDuration := time.Duration(min) * time.Second
+	if cfg.OperationMode == Localhost {
+		if *duration < 0 {
+			errs.add(field+"Period", "must be > 0. Actual is: %s", *duration)
+		}
+	} else if *duration < minDuration {
+		errs.add(field+"Period", "must be >= %s. Actual is: %s", minDuration, *duration)
+	}
+	*seconds = int((*duration + time.Second - 1) / time.Second)
 }
 
-func validConfigRates(cfg *SplitSdkConfig) error {
-	if cfg.OperationMode == RedisConsumer {
-		return nil
+// checkDefaultedPeriod works as checkPeriod, setting the period to defaultSeconds when neither field is set
+func checkDefaultedPeriod(cfg *SplitSdkConfig, errs *ValidationError, field string, seconds *int, duration *time.Duration, min int, defaultSeconds int) {
+	if *seconds == 0 && *duration == 0 {
+		*seconds = defaultSeconds
+		return
+	}
+	checkPeriod(cfg, errs, field, seconds, duration, min)
+}
+
+// checkTimeout rounds up the duration alternative of a timeout in seconds, which takes precedence when set
+func checkTimeout(errs *ValidationError, field string, seconds *int, duration *time.Duration) {
+	if *duration < 0 {
+		errs.add(field, "must be >= 0. Actual is: %s", *duration)
+	} else if *duration > 0 {
+		*seconds = int((*duration + time.Second - 1) / time.Second)
 	}
+}
 
-	if cfg.TaskPeriods.SplitSync < minSplitSync {
-		return fmt.Errorf("SplitSync must be >= %d. Actual is: %d", minSplitSync, cfg.TaskPeriods.SplitSync)
+func checkImpressionSync(cfg *SplitSdkConfig, errs *ValidationError) {
+	checkDefaultedPeriod(cfg, errs, "TaskPeriods.ImpressionSync", &cfg.TaskPeriods.ImpressionSync, &cfg.TaskPeriods.ImpressionSyncPeriod, minImpressionSyncOptimized, defaultImpressionSyncOptimized)
+}
+
+func checkUniqueKeys(cfg *SplitSdkConfig, errs *ValidationError) {
+	checkDefaultedPeriod(cfg, errs, "TaskPeriods.UniqueKeysSync", &cfg.TaskPeriods.UniqueKeysSync, &cfg.TaskPeriods.UniqueKeysSyncPeriod, minUniqueKeysSync, defaultUniqueKeysSync)
+	if cfg.Advanced.UniqueKeysCacheSize == 0 {
+		cfg.Advanced.UniqueKeysCacheSize = defaultUniqueKeysCacheSize
+	} else if cfg.Advanced.UniqueKeysCacheSize < 0 {
+		errs.add("Advanced.UniqueKeysCacheSize", "must be > 0. Actual is: %d", cfg.Advanced.UniqueKeysCacheSize)
 	}
-	if cfg.TaskPeriods.SegmentSync < minSegmentSync {
-		return fmt.Errorf("SegmentSync must be >= %d. Actual is: %d", minSegmentSync, cfg.TaskPeriods.SegmentSync)
+}
+
+// getLogger returns the logger configured by the user, or the sdk's own logger built from LoggerConfig
+func getLogger(cfg *SplitSdkConfig) logging.LoggerInterface {
+	if cfg.Logger != nil {
+		return cfg.Logger
 	}
+	return logging.NewLogger(&cfg.LoggerConfig)
+}
 
-	cfg.ImpressionsMode = strings.ToLower(cfg.ImpressionsMode)
-	switch cfg.ImpressionsMode {
-	case conf.ImpressionsModeOptimized:
-		err := checkImpressionSync(cfg)
-		if err != nil {
-			return err
+// checkImpressionsDisabledFeatures validates the feature patterns, rejecting them in redis-consumer mode, where the
+// impressions of disabled features couldn't be counted
+func checkImpressionsDisabledFeatures(cfg *SplitSdkConfig, errs *ValidationError) {
+	if cfg.OperationMode == RedisConsumer && len(cfg.Advanced.ImpressionsDisabledFeatures) > 0 {
+		errs.add("Advanced.ImpressionsDisabledFeatures", "not supported in redis-consumer mode, where impression counts are not tracked")
+		return
+	}
+	for index, pattern := range cfg.Advanced.ImpressionsDisabledFeatures {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs.add(fmt.Sprintf("Advanced.ImpressionsDisabledFeatures[%d]", index), "invalid feature pattern \"%s\"", pattern)
 		}
-	case conf.ImpressionsModeDebug:
-		if cfg.TaskPeriods.ImpressionSync == 0 {
-			cfg.TaskPeriods.ImpressionSync = defaultImpressionSyncDebug
-		} else {
-			if cfg.TaskPeriods.ImpressionSync < minImpressionSync {
-				return fmt.Errorf("ImpressionSync must be >= %d. Actual is: %d", minImpressionSync, cfg.TaskPeriods.ImpressionSync)
-			}
+	}
+}
+
+func checkImpressionListener(cfg *SplitSdkConfig, errs *ValidationError) {
+	if cfg.Advanced.ImpressionListenerQueueSize < 0 {
+		errs.add("Advanced.ImpressionListenerQueueSize", "must be >= 0. Actual is: %d", cfg.Advanced.ImpressionListenerQueueSize)
+	}
+
+	if cfg.Advanced.ImpressionListenerWorkers <= 0 {
+		cfg.Advanced.ImpressionListenerWorkers = 1
+	}
+
+	if cfg.Advanced.ImpressionListenerBatchSize < 0 {
+		errs.add("Advanced.ImpressionListenerBatchSize", "must be >= 0. Actual is: %d", cfg.Advanced.ImpressionListenerBatchSize)
+	} else if cfg.Advanced.ImpressionListenerBatchSize == 0 {
+		cfg.Advanced.ImpressionListenerBatchSize = 100
+	}
+
+	if cfg.Advanced.ImpressionListenerFlushInterval < 0 {
+		errs.add("Advanced.ImpressionListenerFlushInterval", "must be >= 0. Actual is: %d", cfg.Advanced.ImpressionListenerFlushInterval)
+	} else if cfg.Advanced.ImpressionListenerFlushInterval == 0 {
+		cfg.Advanced.ImpressionListenerFlushInterval = 5
+	}
+
+	policies := set.NewSet(
+		impressionlistener.OverflowPolicyDropOldest,
+		impressionlistener.OverflowPolicyDropNewest,
+		impressionlistener.OverflowPolicyBlock,
+	)
+	cfg.Advanced.ImpressionListenerOverflowPolicy = strings.ToLower(cfg.Advanced.ImpressionListenerOverflowPolicy)
+	if cfg.Advanced.ImpressionListenerOverflowPolicy == "" {
+		cfg.Advanced.ImpressionListenerOverflowPolicy = impressionlistener.OverflowPolicyDropNewest
+	}
+	if !policies.Has(cfg.Advanced.ImpressionListenerOverflowPolicy) {
+		errs.add("Advanced.ImpressionListenerOverflowPolicy", "must be one of: %v", policies.List())
+	}
+
+	for index, filtered := range cfg.Advanced.ImpressionListeners {
+		field := fmt.Sprintf("Advanced.ImpressionListeners[%d]", index)
+		if filtered.Listener == nil {
+			errs.add(field+".Listener", "must be non-nil")
 		}
-	default:
-		fmt.Println(`You passed an invalid impressionsMode, impressionsMode should be one of the following values: 'debug' or 'optimized'. Defaulting to 'optimized' mode.`)
-		cfg.ImpressionsMode = conf.ImpressionsModeOptimized
-		err := checkImpressionSync(cfg)
-		if err != nil {
-			return err
+		if err := filtered.Filter.Validate(); err != nil {
+			errs.add(field+".Filter", "%s", err.Error())
 		}
 	}
+}
 
-	if cfg.TaskPeriods.EventsSync < minEventSync {
-		return fmt.Errorf("EventsSync must be >= %d. Actual is: %d", minEventSync, cfg.TaskPeriods.EventsSync)
+func checkLocalhostSources(cfg *SplitSdkConfig, errs *ValidationError) {
+	if cfg.SplitData != nil && cfg.SplitReader != nil {
+		errs.add("SplitReader", "cannot be combined with SplitData")
+	}
+	if len(cfg.SplitFiles) > 0 && (cfg.SplitData != nil || cfg.SplitReader != nil) {
+		errs.add("SplitFiles", "cannot be combined with SplitData or SplitReader")
+	}
+	for index, splitFile := range cfg.SplitFiles {
+		if strings.TrimSpace(splitFile) == "" {
+			errs.add(fmt.Sprintf("SplitFiles[%d]", index), "must be a non-empty path")
+		}
 	}
-	if cfg.TaskPeriods.LatencySync < minTelemetrySync {
-		return fmt.Errorf("LatencySync must be >= %d. Actual is: %d", minTelemetrySync, cfg.TaskPeriods.LatencySync)
+}
+
+// checkRedisImpressionsMode rejects the "none" impressions mode in redis-consumer mode, where the impression counts
+// and unique keys it relies on are not tracked
+func checkRedisImpressionsMode(cfg *SplitSdkConfig, errs *ValidationError) {
+	if cfg.OperationMode == RedisConsumer && strings.ToLower(cfg.ImpressionsMode) == ImpressionsModeNone {
+		errs.add("ImpressionsMode", "'none' is not supported in redis-consumer mode")
 	}
-	if cfg.TaskPeriods.GaugeSync < minTelemetrySync {
-		return fmt.Errorf("GaugeSync must be >= %d. Actual is: %d", minTelemetrySync, cfg.TaskPeriods.GaugeSync)
+}
+
+func validConfigRates(cfg *SplitSdkConfig, logger logging.LoggerInterface, errs *ValidationError) {
+	if cfg.OperationMode == RedisConsumer {
+		return
 	}
-	if cfg.TaskPeriods.CounterSync < minTelemetrySync {
-		return fmt.Errorf("CounterSync must be >= %d. Actual is: %d", minTelemetrySync, cfg.TaskPeriods.CounterSync)
+
+	periods := &cfg.TaskPeriods
+	checkPeriod(cfg, errs, "TaskPeriods.SplitSync", &periods.SplitSync, &periods.SplitSyncPeriod, minSplitSync)
+	checkPeriod(cfg, errs, "TaskPeriods.SegmentSync", &periods.SegmentSync, &periods.SegmentSyncPeriod, minSegmentSync)
+
+	cfg.ImpressionsMode = strings.ToLower(cfg.ImpressionsMode)
+	switch cfg.ImpressionsMode {
+	case conf.ImpressionsModeOptimized:
+		checkImpressionSync(cfg, errs)
+	case conf.ImpressionsModeDebug:
+		checkDefaultedPeriod(cfg, errs, "TaskPeriods.ImpressionSync", &periods.ImpressionSync, &periods.ImpressionSyncPeriod, minImpressionSync, defaultImpressionSyncDebug)
+	case ImpressionsModeNone:
+		checkUniqueKeys(cfg, errs)
+	default:
+		logger.Warning(`You passed an invalid impressionsMode, impressionsMode should be one of the following values: 'debug', 'optimized' or 'none'. Defaulting to 'optimized' mode.`)
+		cfg.ImpressionsMode = conf.ImpressionsModeOptimized
+		checkImpressionSync(cfg, errs)
 	}
+
+	checkPeriod(cfg, errs, "TaskPeriods.EventsSync", &periods.EventsSync, &periods.EventsSyncPeriod, minEventSync)
+	checkPeriod(cfg, errs, "TaskPeriods.LatencySync", &periods.LatencySync, &periods.LatencySyncPeriod, minTelemetrySync)
+	checkPeriod(cfg, errs, "TaskPeriods.GaugeSync", &periods.GaugeSync, &periods.GaugeSyncPeriod, minTelemetrySync)
+	checkPeriod(cfg, errs, "TaskPeriods.CounterSync", &periods.CounterSync, &periods.CounterSyncPeriod, minTelemetrySync)
 	if cfg.Advanced.SegmentWorkers <= 0 {
-		return errors.New("Number of workers for fetching segments MUST be greater than zero")
+		errs.add("Advanced.SegmentWorkers", "must be > 0. Actual is: %d", cfg.Advanced.SegmentWorkers)
 	}
-	return nil
 }
 
-// Normalize checks that the parameters passed by the user are correct and updates parameters if necessary.
-// returns an error if something is wrong
-func Normalize(apikey string, cfg *SplitSdkConfig) error {
-	// Fail if no apikey is provided
-	if apikey == "" && cfg.OperationMode != Localhost {
-		return errors.New("Factory instantiation: you passed an empty apikey, apikey must be a non-empty string")
+// applyProxyURL points every service URL to SplitSyncProxyURL, warning about the URLs it overrides
+func applyProxyURL(cfg *SplitSdkConfig, logger logging.LoggerInterface) {
+	urls := []struct {
+		field string
+		value *string
+	}{
+		{"AuthServiceURL", &cfg.Advanced.AuthServiceURL},
+		{"SdkURL", &cfg.Advanced.SdkURL},
+		{"EventsURL", &cfg.Advanced.EventsURL},
+		{"StreamingServiceURL", &cfg.Advanced.StreamingServiceURL},
 	}
-
-	// To keep the interface consistent with other sdks we accept "localhost" as an apikey,
-	// which sets the operation mode to localhost
-	if apikey == Localhost {
-		cfg.OperationMode = Localhost
+	for _, url := range urls {
+		if *url.value != "" && *url.value != cfg.SplitSyncProxyURL {
+			logger.Warning(fmt.Sprintf("SplitSyncProxyURL is set, overriding Advanced.%s: %s", url.field, *url.value))
+		}
+		*url.value = cfg.SplitSyncProxyURL
 	}
+}
+
+// normalize validates cfg, updating the parameters that can be defaulted, and collects every problem found
+func normalize(cfg *SplitSdkConfig, errs *ValidationError) {
+	logger := getLogger(cfg)
 
 	// Fail if an invalid operation-mode is provided
 	operationModes := set.NewSet(
@@ -240,14 +432,11 @@ func Normalize(apikey string, cfg *SplitSdkConfig) error {
 	)
 
 	if !operationModes.Has(cfg.OperationMode) {
-		return fmt.Errorf("OperationMode parameter must be one of: %v", operationModes.List())
+		errs.add("OperationMode", "must be one of: %v", operationModes.List())
 	}
 
 	if cfg.SplitSyncProxyURL != "" {
-		cfg.Advanced.AuthServiceURL = cfg.SplitSyncProxyURL
-		cfg.Advanced.SdkURL = cfg.SplitSyncProxyURL
-		cfg.Advanced.EventsURL = cfg.SplitSyncProxyURL
-		cfg.Advanced.StreamingServiceURL = cfg.SplitSyncProxyURL
+		applyProxyURL(cfg, logger)
 	}
 
 	if !cfg.IPAddressesEnabled {
@@ -255,5 +444,40 @@ func Normalize(apikey string, cfg *SplitSdkConfig) error {
 		cfg.InstanceName = "NA"
 	}
 
-	return validConfigRates(cfg)
+	checkTimeout(errs, "BlockUntilReadyTimeout", &cfg.BlockUntilReady, &cfg.BlockUntilReadyTimeout)
+	checkTimeout(errs, "Advanced.HTTPTimeoutDuration", &cfg.Advanced.HTTPTimeout, &cfg.Advanced.HTTPTimeoutDuration)
+	checkLocalhostSources(cfg, errs)
+	checkImpressionListener(cfg, errs)
+	checkImpressionsDisabledFeatures(cfg, errs)
+	checkRedisImpressionsMode(cfg, errs)
+	validConfigRates(cfg, logger, errs)
+}
+
+// Normalize checks that the parameters passed by the user are correct and updates parameters if necessary.
+// Returns a *ValidationError with every problem found, if any. Non-fatal adjustments are logged as warnings
+func Normalize(apikey string, cfg *SplitSdkConfig) error {
+	errs := &ValidationError{}
+
+	// Fail if no apikey is provided
+	if apikey == "" && cfg.OperationMode != Localhost {
+		errs.add("apikey", "Factory instantiation: you passed an empty apikey, apikey must be a non-empty string")
+	}
+
+	// To keep the interface consistent with other sdks we accept "localhost" as an apikey,
+	// which sets the operation mode to localhost
+	if apikey == Localhost {
+		cfg.OperationMode = Localhost
+	}
+
+	normalize(cfg, errs)
+	return errs.orNil()
+}
+
+// Validate returns a *ValidationError with every problem found in the config, if any, without modifying it. It
+// lets services check their config before creating a factory
+func (c *SplitSdkConfig) Validate() error {
+	normalized := *c
+	errs := &ValidationError{}
+	normalize(&normalized, errs)
+	return errs.orNil()
 }
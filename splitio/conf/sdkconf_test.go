@@ -1,8 +1,11 @@
 package conf
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
 	"github.com/splitio/go-split-commons/conf"
 )
 
@@ -54,42 +57,42 @@ func TestValidRates(t *testing.T) {
 
 	cfg.TaskPeriods.CounterSync = 0
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "CounterSync must be >= 30. Actual is: 0" {
+	if err == nil || err.Error() != "TaskPeriods.CounterSync: must be >= 30. Actual is: 0" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default()
 	cfg.TaskPeriods.LatencySync = 10
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "LatencySync must be >= 30. Actual is: 10" {
+	if err == nil || err.Error() != "TaskPeriods.LatencySync: must be >= 30. Actual is: 10" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default()
 	cfg.TaskPeriods.GaugeSync = 20
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "GaugeSync must be >= 30. Actual is: 20" {
+	if err == nil || err.Error() != "TaskPeriods.GaugeSync: must be >= 30. Actual is: 20" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default()
 	cfg.TaskPeriods.SplitSync = 4
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "SplitSync must be >= 5. Actual is: 4" {
+	if err == nil || err.Error() != "TaskPeriods.SplitSync: must be >= 5. Actual is: 4" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default()
 	cfg.TaskPeriods.SegmentSync = 29
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "SegmentSync must be >= 30. Actual is: 29" {
+	if err == nil || err.Error() != "TaskPeriods.SegmentSync: must be >= 30. Actual is: 29" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default() // Optimized by Default
 	cfg.TaskPeriods.ImpressionSync = 59
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "ImpressionSync must be >= 60. Actual is: 59" {
+	if err == nil || err.Error() != "TaskPeriods.ImpressionSync: must be >= 60. Actual is: 59" {
 		t.Error("It should return err")
 	}
 
@@ -104,21 +107,21 @@ func TestValidRates(t *testing.T) {
 	cfg.TaskPeriods.ImpressionSync = -1
 	cfg.ImpressionsMode = conf.ImpressionsModeDebug
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "ImpressionSync must be >= 1. Actual is: -1" {
+	if err == nil || err.Error() != "TaskPeriods.ImpressionSync: must be >= 1. Actual is: -1" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default()
 	cfg.TaskPeriods.EventsSync = 0
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "EventsSync must be >= 1. Actual is: 0" {
+	if err == nil || err.Error() != "TaskPeriods.EventsSync: must be >= 1. Actual is: 0" {
 		t.Error("It should return err")
 	}
 
 	cfg = Default()
 	cfg.Advanced.SegmentWorkers = 0
 	err = Normalize("asd", cfg)
-	if err == nil || err.Error() != "Number of workers for fetching segments MUST be greater than zero" {
+	if err == nil || err.Error() != "Advanced.SegmentWorkers: must be > 0. Actual is: 0" {
 		t.Error("It should return err")
 	}
 
@@ -129,3 +132,245 @@ func TestValidRates(t *testing.T) {
 		t.Error("It should not return err")
 	}
 }
+
+func TestImpressionsModeNone(t *testing.T) {
+	cfg := Default()
+	cfg.ImpressionsMode = "NONE"
+	cfg.TaskPeriods.UniqueKeysSync = 0
+	cfg.Advanced.UniqueKeysCacheSize = 0
+	err := Normalize("asd", cfg)
+	if err != nil || cfg.ImpressionsMode != ImpressionsModeNone {
+		t.Error("It should not return err")
+	}
+	if cfg.TaskPeriods.UniqueKeysSync != 900 || cfg.Advanced.UniqueKeysCacheSize != 30000 {
+		t.Error("It should set defaults")
+	}
+
+	cfg = Default()
+	cfg.ImpressionsMode = ImpressionsModeNone
+	cfg.TaskPeriods.UniqueKeysSync = 10
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "TaskPeriods.UniqueKeysSync: must be >= 30. Actual is: 10" {
+		t.Error("It should return err")
+	}
+
+	cfg = Default()
+	cfg.ImpressionsMode = ImpressionsModeNone
+	cfg.Advanced.UniqueKeysCacheSize = -1
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "Advanced.UniqueKeysCacheSize: must be > 0. Actual is: -1" {
+		t.Error("It should return err")
+	}
+
+	cfg = Default()
+	cfg.OperationMode = RedisConsumer
+	cfg.ImpressionsMode = ImpressionsModeNone
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "ImpressionsMode: 'none' is not supported in redis-consumer mode" {
+		t.Error("It should return err")
+	}
+}
+
+func TestImpressionListenerOptions(t *testing.T) {
+	cfg := Default()
+	err := Normalize("asd", cfg)
+	if err != nil || cfg.Advanced.ImpressionListenerOverflowPolicy != impressionlistener.OverflowPolicyDropNewest {
+		t.Error("It should default to drop_newest")
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionListenerOverflowPolicy = "Drop_Oldest"
+	cfg.Advanced.ImpressionListenerWorkers = 0
+	err = Normalize("asd", cfg)
+	if err != nil || cfg.Advanced.ImpressionListenerOverflowPolicy != impressionlistener.OverflowPolicyDropOldest || cfg.Advanced.ImpressionListenerWorkers != 1 {
+		t.Error("It should normalize listener options")
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionListenerOverflowPolicy = "some"
+	err = Normalize("asd", cfg)
+	if err == nil {
+		t.Error("It should return err")
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionListenerQueueSize = -1
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "Advanced.ImpressionListenerQueueSize: must be >= 0. Actual is: -1" {
+		t.Error("It should return err")
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionListenerBatchSize = -1
+	cfg.Advanced.ImpressionListenerFlushInterval = -5
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "Advanced.ImpressionListenerBatchSize: must be >= 0. Actual is: -1; Advanced.ImpressionListenerFlushInterval: must be >= 0. Actual is: -5" {
+		t.Error("It should reject negative batch size and flush interval", err)
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionListenerBatchSize = 0
+	cfg.Advanced.ImpressionListenerFlushInterval = 0
+	err = Normalize("asd", cfg)
+	if err != nil || cfg.Advanced.ImpressionListenerBatchSize != 100 || cfg.Advanced.ImpressionListenerFlushInterval != 5 {
+		t.Error("It should default batch size and flush interval when unset")
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionListeners = []impressionlistener.FilteredListener{{Listener: nil}}
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "Advanced.ImpressionListeners[0].Listener: must be non-nil" {
+		t.Error("It should return err")
+	}
+}
+
+func TestImpressionsDisabledFeatures(t *testing.T) {
+	cfg := Default()
+	cfg.Advanced.ImpressionsDisabledFeatures = []string{"kill_switch_*", "heartbeat"}
+	err := Normalize("asd", cfg)
+	if err != nil {
+		t.Error("It should not return err")
+	}
+	if !cfg.Advanced.IsImpressionsDisabled("kill_switch_checkout") || !cfg.Advanced.IsImpressionsDisabled("heartbeat") {
+		t.Error("Impressions should be disabled for matching features")
+	}
+	if cfg.Advanced.IsImpressionsDisabled("checkout") {
+		t.Error("Impressions should be enabled for other features")
+	}
+
+	cfg = Default()
+	cfg.Advanced.ImpressionsDisabledFeatures = []string{"["}
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "Advanced.ImpressionsDisabledFeatures[0]: invalid feature pattern \"[\"" {
+		t.Error("It should return err")
+	}
+
+	cfg = Default()
+	cfg.OperationMode = RedisConsumer
+	cfg.Advanced.ImpressionsDisabledFeatures = []string{"heartbeat"}
+	err = Normalize("asd", cfg)
+	if err == nil || err.Error() != "Advanced.ImpressionsDisabledFeatures: not supported in redis-consumer mode, where impression counts are not tracked" {
+		t.Error("It should be rejected in redis-consumer mode. Actual:", err)
+	}
+}
+
+func TestLocalhostSplitSources(t *testing.T) {
+	cfg := Default()
+	cfg.SplitData = []byte("feature on\n")
+	if err := Normalize("localhost", cfg); err != nil {
+		t.Error("SplitData should be accepted", err)
+	}
+
+	cfg.SplitReader = strings.NewReader("feature on\n")
+	if err := Normalize("localhost", cfg); err == nil || err.Error() != "SplitReader: cannot be combined with SplitData" {
+		t.Error("SplitData and SplitReader should not be combined", err)
+	}
+
+	cfg = Default()
+	cfg.SplitFiles = []string{"shared.yaml", " "}
+	if err := Normalize("localhost", cfg); err == nil || err.Error() != "SplitFiles[1]: must be a non-empty path" {
+		t.Error("Empty paths should be rejected", err)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	cfg := Default()
+	cfg.OperationMode = "invalid_mode"
+	cfg.TaskPeriods.SplitSync = 4
+	cfg.TaskPeriods.CounterSync = 0
+	cfg.Advanced.SegmentWorkers = 0
+	cfg.Advanced.ImpressionListenerQueueSize = -1
+	err := Normalize("", cfg)
+
+	validationError, ok := err.(*ValidationError)
+	if !ok {
+		t.Error("It should return a ValidationError")
+		return
+	}
+	expected := []string{
+		"apikey",
+		"OperationMode",
+		"Advanced.ImpressionListenerQueueSize",
+		"TaskPeriods.SplitSync",
+		"TaskPeriods.CounterSync",
+		"Advanced.SegmentWorkers",
+	}
+	fields := validationError.Fields()
+	if len(fields) != len(expected) {
+		t.Error("It should report every problem. Actual is:", fields)
+		return
+	}
+	for index, field := range expected {
+		if fields[index] != field {
+			t.Error("Unexpected field", fields[index], "expected", field)
+		}
+	}
+	if !strings.Contains(err.Error(), "TaskPeriods.SplitSync: must be >= 5. Actual is: 4; TaskPeriods.CounterSync: must be >= 30. Actual is: 0") {
+		t.Error("It should join the messages. Actual is:", err.Error())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Error("It should not return err", err)
+	}
+
+	cfg.ImpressionsMode = "DEBUG"
+	cfg.TaskPeriods.ImpressionSync = 0
+	cfg.TaskPeriods.EventsSync = 0
+	err := cfg.Validate()
+	if err == nil || err.Error() != "TaskPeriods.EventsSync: must be >= 1. Actual is: 0" {
+		t.Error("It should return err")
+	}
+	if cfg.ImpressionsMode != "DEBUG" || cfg.TaskPeriods.ImpressionSync != 0 {
+		t.Error("It should not modify the config")
+	}
+	if fieldError := err.(*ValidationError).Errors[0]; fieldError.Field != "TaskPeriods.EventsSync" {
+		t.Error("Unexpected field", fieldError.Field)
+	}
+}
+
+func TestDurationPeriods(t *testing.T) {
+	cfg := Default()
+	cfg.TaskPeriods.SplitSyncPeriod = 90 * time.Second
+	cfg.TaskPeriods.EventsSyncPeriod = 1500 * time.Millisecond
+	cfg.Advanced.HTTPTimeoutDuration = 10 * time.Second
+	cfg.BlockUntilReadyTimeout = 2500 * time.Millisecond
+	err := Normalize("asd", cfg)
+	if err != nil {
+		t.Error("It should not return err", err)
+	}
+	if cfg.TaskPeriods.SplitSync != 90 || cfg.TaskPeriods.EventsSync != 2 || cfg.Advanced.HTTPTimeout != 10 {
+		t.Error("Durations should take precedence and be rounded up to seconds")
+	}
+	if cfg.BlockUntilReady != 3 || cfg.TaskPeriods.SegmentSync != defaultTaskPeriod {
+		t.Error("Seconds should be kept when no duration is set")
+	}
+
+	cfg = Default()
+	cfg.TaskPeriods.SplitSyncPeriod = 500 * time.Millisecond
+	cfg.Advanced.HTTPTimeoutDuration = -time.Second
+	err = Normalize("asd", cfg)
+	validationError, ok := err.(*ValidationError)
+	if !ok || len(validationError.Errors) != 2 {
+		t.Error("It should return a ValidationError with both problems")
+		return
+	}
+	if fieldError := validationError.Errors[0]; fieldError.Field != "Advanced.HTTPTimeoutDuration" || fieldError.Message != "must be >= 0. Actual is: -1s" {
+		t.Error("Unexpected error", fieldError.Field, fieldError.Message)
+	}
+	if fieldError := validationError.Errors[1]; fieldError.Field != "TaskPeriods.SplitSyncPeriod" || fieldError.Message != "must be >= 5s. Actual is: 500ms" {
+		t.Error("Unexpected error", fieldError.Field, fieldError.Message)
+	}
+
+	cfg = Default()
+	cfg.TaskPeriods.SplitSyncPeriod = 100 * time.Millisecond
+	err = Normalize(Localhost, cfg)
+	if err != nil {
+		t.Error("Sub-second periods should be allowed in localhost mode", err)
+	}
+	if cfg.TaskPeriods.SplitSync != 1 {
+		t.Error("Sub-second periods should be rounded up to one second")
+	}
+}
@@ -0,0 +1,55 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a problem found in a single config parameter
+// - Field - Path of the parameter in SplitSdkConfig (ie: "TaskPeriods.SplitSync", "Advanced.SegmentWorkers")
+// - Message - Description of the problem, without the path of the parameter
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error returns the description of the problem prefixed by the path of the parameter
+// (ie: "TaskPeriods.SplitSync: must be >= 5. Actual is: 4")
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when validating a config fails, and holds every problem found
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error returns the description of every problem found, separated by "; "
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		messages = append(messages, fieldError.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields returns the path of every parameter with problems, in the order they were found
+func (e *ValidationError) Fields() []string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		fields = append(fields, fieldError.Field)
+	}
+	return fields
+}
+
+func (e *ValidationError) add(field string, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// orNil returns nil if no problem was found, so that callers get an untyped nil error
+func (e *ValidationError) orNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,270 @@
+package dependencygraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/splitio/go-client/splitio/engine/grammar/matchers"
+	"github.com/splitio/go-split-commons/dtos"
+)
+
+const (
+	// NodeTypeSplit is the type of the nodes representing splits
+	NodeTypeSplit = "split"
+	// NodeTypeSegment is the type of the nodes representing segments
+	NodeTypeSegment = "segment"
+)
+
+// Node struct representing a split or a segment in the graph
+// - Name - Name of the split or segment
+// - Type - Either 'split' or 'segment'
+// - Missing - True when the node is referenced but not present in storage
+type Node struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Missing bool   `json:"missing"`
+}
+
+// Edge struct representing a split referencing another split or a segment
+// - From - Name of the split holding the matcher
+// - To - Name of the referenced split or segment
+// - Type - Type of the target node
+// - Treatments - Treatments of the target split the matcher checks for. Empty for segments
+type Edge struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Type       string   `json:"type"`
+	Treatments []string `json:"treatments,omitempty"`
+}
+
+// Graph struct holding the dependencies between splits and segments
+type Graph struct {
+	Nodes   []Node     `json:"nodes"`
+	Edges   []Edge     `json:"edges"`
+	Missing []Node     `json:"missing"`
+	Cycles  [][]string `json:"cycles"`
+}
+
+// Build creates the graph of dependencies of the splits. segmentExists tells whether a referenced segment is stored.
+// The splits slice is not modified
+func Build(splits []dtos.SplitDTO, segmentExists func(name string) bool) *Graph {
+	splits = append([]dtos.SplitDTO(nil), splits...)
+	sort.Slice(splits, func(i, j int) bool { return splits[i].Name < splits[j].Name })
+
+	graph := &Graph{
+		Nodes:   make([]Node, 0),
+		Edges:   make([]Edge, 0),
+		Missing: make([]Node, 0),
+		Cycles:  make([][]string, 0),
+	}
+	storedSplits := make(map[string]struct{}, len(splits))
+	for _, split := range splits {
+		storedSplits[split.Name] = struct{}{}
+		graph.Nodes = append(graph.Nodes, Node{Name: split.Name, Type: NodeTypeSplit})
+	}
+
+	segments := make(map[string]struct{})
+	missingSplits := make(map[string]struct{})
+	seenEdges := make(map[string]struct{})
+	for _, split := range splits {
+		for _, condition := range split.Conditions {
+			for _, matcher := range condition.MatcherGroup.Matchers {
+				var edge Edge
+				switch {
+				case matcher.MatcherType == matchers.MatcherTypeInSegment && matcher.UserDefinedSegment != nil:
+					edge = Edge{From: split.Name, To: matcher.UserDefinedSegment.SegmentName, Type: NodeTypeSegment}
+					segments[edge.To] = struct{}{}
+				case matcher.MatcherType == matchers.MatcherTypeInSplitTreatment && matcher.Dependency != nil:
+					edge = Edge{From: split.Name, To: matcher.Dependency.Split, Type: NodeTypeSplit, Treatments: matcher.Dependency.Treatments}
+					if _, ok := storedSplits[edge.To]; !ok {
+						missingSplits[edge.To] = struct{}{}
+					}
+				default:
+					continue
+				}
+
+				edgeID := edge.Type + "\x00" + edge.From + "\x00" + edge.To + "\x00" + strings.Join(edge.Treatments, ",")
+				if _, ok := seenEdges[edgeID]; ok {
+					continue
+				}
+				seenEdges[edgeID] = struct{}{}
+				graph.Edges = append(graph.Edges, edge)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(missingSplits) {
+		node := Node{Name: name, Type: NodeTypeSplit, Missing: true}
+		graph.Nodes = append(graph.Nodes, node)
+		graph.Missing = append(graph.Missing, node)
+	}
+	for _, name := range sortedKeys(segments) {
+		node := Node{Name: name, Type: NodeTypeSegment, Missing: segmentExists != nil && !segmentExists(name)}
+		graph.Nodes = append(graph.Nodes, node)
+		if node.Missing {
+			graph.Missing = append(graph.Missing, node)
+		}
+	}
+
+	graph.Cycles = findCycles(graph)
+	return graph
+}
+
+// nodeKey identifies a node by type and name, since a split and a segment can share a name
+type nodeKey struct {
+	nodeType string
+	name     string
+}
+
+// Dependents returns the splits that depend on the split or segment with the given type (NodeTypeSplit or
+// NodeTypeSegment) and name, directly or through other splits
+func (g *Graph) Dependents(nodeType string, name string) []string {
+	reverse := make(map[nodeKey][]string)
+	for _, edge := range g.Edges {
+		target := nodeKey{nodeType: edge.Type, name: edge.To}
+		reverse[target] = append(reverse[target], edge.From)
+	}
+
+	visited := map[string]struct{}{}
+	pending := []nodeKey{{nodeType: nodeType, name: name}}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		for _, dependent := range reverse[current] {
+			if _, ok := visited[dependent]; ok {
+				continue
+			}
+			visited[dependent] = struct{}{}
+			pending = append(pending, nodeKey{nodeType: NodeTypeSplit, name: dependent})
+		}
+	}
+	if nodeType == NodeTypeSplit {
+		delete(visited, name)
+	}
+	return sortedKeys(visited)
+}
+
+// WriteJSON writes the graph as JSON
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Segments are drawn as boxes and missing nodes are dashed
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var builder bytes.Buffer
+	builder.WriteString("digraph splits {\n")
+	for _, node := range g.Nodes {
+		attributes := []string{"label=" + quote(node.Name)}
+		if node.Type == NodeTypeSegment {
+			attributes = append(attributes, "shape=box")
+		}
+		if node.Missing {
+			attributes = append(attributes, "style=dashed", "color=red")
+		}
+		builder.WriteString(fmt.Sprintf("  %s [%s];\n", dotID(node), strings.Join(attributes, ", ")))
+	}
+	for _, edge := range g.Edges {
+		builder.WriteString(fmt.Sprintf("  %s -> %s", dotID(Node{Name: edge.From, Type: NodeTypeSplit}), dotID(Node{Name: edge.To, Type: edge.Type})))
+		if len(edge.Treatments) > 0 {
+			builder.WriteString(fmt.Sprintf(" [label=%s]", quote(strings.Join(edge.Treatments, ","))))
+		}
+		builder.WriteString(";\n")
+	}
+	builder.WriteString("}\n")
+	_, err := builder.WriteTo(w)
+	return err
+}
+
+// findCycles returns the groups of splits that depend on each other, using Tarjan's strongly connected components
+func findCycles(g *Graph) [][]string {
+	adjacency := make(map[string][]string)
+	for _, edge := range g.Edges {
+		if edge.Type == NodeTypeSplit {
+			adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+		}
+	}
+
+	index := 0
+	indexes := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	cycles := make([][]string, 0)
+
+	var connect func(node string)
+	connect = func(node string) {
+		indexes[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		selfLoop := false
+		for _, target := range adjacency[node] {
+			if target == node {
+				selfLoop = true
+			}
+			if _, visited := indexes[target]; !visited {
+				connect(target)
+				if lowLinks[target] < lowLinks[node] {
+					lowLinks[node] = lowLinks[target]
+				}
+			} else if onStack[target] && indexes[target] < lowLinks[node] {
+				lowLinks[node] = indexes[target]
+			}
+		}
+
+		if lowLinks[node] != indexes[node] {
+			return
+		}
+		component := make([]string, 0)
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if node.Type != NodeTypeSplit {
+			continue
+		}
+		if _, visited := indexes[node.Name]; !visited {
+			connect(node.Name)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+func dotID(node Node) string {
+	return quote(node.Type + ":" + node.Name)
+}
+
+func quote(value string) string {
+	return "\"" + strings.Replace(strings.Replace(value, "\\", "\\\\", -1), "\"", "\\\"", -1) + "\""
+}
+
+func sortedKeys(items map[string]struct{}) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package dependencygraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-split-commons/dtos"
+)
+
+func splitWithMatchers(name string, matchers ...dtos.MatcherDTO) dtos.SplitDTO {
+	return dtos.SplitDTO{
+		Name:       name,
+		Conditions: []dtos.ConditionDTO{{MatcherGroup: dtos.MatcherGroupDTO{Matchers: matchers}}},
+	}
+}
+
+func inSegment(segment string) dtos.MatcherDTO {
+	return dtos.MatcherDTO{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: segment}}
+}
+
+func inSplit(split string, treatments ...string) dtos.MatcherDTO {
+	return dtos.MatcherDTO{MatcherType: "IN_SPLIT_TREATMENT", Dependency: &dtos.DependencyMatcherDataDTO{Split: split, Treatments: treatments}}
+}
+
+func testSplits() []dtos.SplitDTO {
+	return []dtos.SplitDTO{
+		splitWithMatchers("checkout", inSplit("payments", "on"), inSegment("employees"), inSegment("employees")),
+		splitWithMatchers("payments", inSplit("legacy", "off"), inSegment("beta")),
+		splitWithMatchers("a", inSplit("b", "on")),
+		splitWithMatchers("b", inSplit("a", "on")),
+		splitWithMatchers("self", inSplit("self", "on")),
+		splitWithMatchers("standalone"),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	graph := Build(testSplits(), func(name string) bool { return name == "employees" })
+
+	if len(graph.Nodes) != 9 {
+		t.Error("Splits, missing splits and segments should be nodes. Actual:", graph.Nodes)
+	}
+	if len(graph.Edges) != 7 {
+		t.Error("Repeated matchers should not duplicate edges. Actual:", graph.Edges)
+	}
+	if len(graph.Missing) != 2 || graph.Missing[0].Name != "legacy" || graph.Missing[1].Name != "beta" {
+		t.Error("Missing split and segment should be reported. Actual:", graph.Missing)
+	}
+	if len(graph.Cycles) != 2 || strings.Join(graph.Cycles[0], ",") != "a,b" || strings.Join(graph.Cycles[1], ",") != "self" {
+		t.Error("Cycles should be reported. Actual:", graph.Cycles)
+	}
+
+	dependents := graph.Dependents(NodeTypeSplit, "legacy")
+	if strings.Join(dependents, ",") != "checkout,payments" {
+		t.Error("Transitive dependents should be returned. Actual:", dependents)
+	}
+	if len(graph.Dependents(NodeTypeSplit, "a")) != 1 || graph.Dependents(NodeTypeSplit, "a")[0] != "b" {
+		t.Error("A split should not be its own dependent. Actual:", graph.Dependents(NodeTypeSplit, "a"))
+	}
+	if len(graph.Dependents(NodeTypeSplit, "standalone")) != 0 {
+		t.Error("Standalone split should have no dependents")
+	}
+	if dependents := graph.Dependents(NodeTypeSegment, "beta"); strings.Join(dependents, ",") != "checkout,payments" {
+		t.Error("Splits depending on a segment should be returned. Actual:", dependents)
+	}
+
+	shared := Build([]dtos.SplitDTO{
+		splitWithMatchers("employees", inSegment("vip")),
+		splitWithMatchers("bySplit", inSplit("employees", "on")),
+		splitWithMatchers("bySegment", inSegment("employees")),
+	}, nil)
+	if dependents := shared.Dependents(NodeTypeSegment, "employees"); strings.Join(dependents, ",") != "bySegment" {
+		t.Error("A segment should not be merged with a split of the same name. Actual:", dependents)
+	}
+	if dependents := shared.Dependents(NodeTypeSplit, "employees"); strings.Join(dependents, ",") != "bySplit" {
+		t.Error("A split should not be merged with a segment of the same name. Actual:", dependents)
+	}
+
+	unsorted := []dtos.SplitDTO{splitWithMatchers("b"), splitWithMatchers("a")}
+	Build(unsorted, nil)
+	if unsorted[0].Name != "b" || unsorted[1].Name != "a" {
+		t.Error("The splits passed should not be reordered. Actual:", unsorted)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	graph := Build(testSplits(), nil)
+	var buffer bytes.Buffer
+	err := graph.WriteJSON(&buffer)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+	}
+
+	var decoded Graph
+	err = json.Unmarshal(buffer.Bytes(), &decoded)
+	if err != nil {
+		t.Error("It should be valid JSON. Actual:", err)
+	}
+	if len(decoded.Nodes) != len(graph.Nodes) || len(decoded.Edges) != len(graph.Edges) || len(decoded.Cycles) != 2 {
+		t.Error("Graph should be encoded completely")
+	}
+	if len(decoded.Missing) != 1 {
+		t.Error("Segments should not be reported missing without a way to check them. Actual:", decoded.Missing)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	graph := Build([]dtos.SplitDTO{splitWithMatchers("checkout", inSplit("pay\"ments", "on", "v2"), inSegment("employees"))}, nil)
+	var buffer bytes.Buffer
+	err := graph.WriteDOT(&buffer)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+	}
+
+	expected := "digraph splits {\n" +
+		"  \"split:checkout\" [label=\"checkout\"];\n" +
+		"  \"split:pay\\\"ments\" [label=\"pay\\\"ments\", style=dashed, color=red];\n" +
+		"  \"segment:employees\" [label=\"employees\", shape=box];\n" +
+		"  \"split:checkout\" -> \"split:pay\\\"ments\" [label=\"on,v2\"];\n" +
+		"  \"split:checkout\" -> \"segment:employees\";\n" +
+		"}\n"
+	if buffer.String() != expected {
+		t.Error("Unexpected DOT output:\n", buffer.String())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/splitio/go-client/splitio/engine"
 	"github.com/splitio/go-client/splitio/engine/evaluator/impressionlabels"
 	"github.com/splitio/go-client/splitio/engine/grammar"
+	"github.com/splitio/go-client/splitio/overrides"
 	"github.com/splitio/go-split-commons/dtos"
 	"github.com/splitio/go-split-commons/storage"
 
@@ -40,6 +41,7 @@ type Evaluator struct {
 	splitStorage   storage.SplitStorageConsumer
 	segmentStorage storage.SegmentStorageConsumer
 	eng            *engine.Engine
+	overrides      *overrides.Store
 	logger         logging.LoggerInterface
 }
 
@@ -58,8 +60,29 @@ func NewEvaluator(
 	}
 }
 
+// SetOverrides sets the store whose forced treatments take precedence over the split definitions. It must be
+// called before the evaluator is used
+func (e *Evaluator) SetOverrides(store *overrides.Store) {
+	e.overrides = store
+}
+
 func (e *Evaluator) evaluateTreatment(key string, bucketingKey string, feature string, splitDto *dtos.SplitDTO, attributes map[string]interface{}) *Result {
 	var config *string
+	if e.overrides != nil {
+		if treatment, config, ok := e.overrides.Get(feature, key); ok {
+			var changeNumber int64
+			if splitDto != nil {
+				changeNumber = splitDto.ChangeNumber
+			}
+			return &Result{
+				Treatment:         treatment,
+				Label:             impressionlabels.Override,
+				SplitChangeNumber: changeNumber,
+				Config:            config,
+			}
+		}
+	}
+
 	if splitDto == nil {
 		e.logger.Warning(fmt.Sprintf("Feature %s not found, returning control.", feature))
 		return &Result{Treatment: Control, Label: impressionlabels.SplitNotFound, Config: config}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/engine/evaluator/impressionlabels"
+	"github.com/splitio/go-client/splitio/overrides"
 	"github.com/splitio/go-split-commons/dtos"
 	"github.com/splitio/go-toolkit/datastructures/set"
 	"github.com/splitio/go-toolkit/logging"
@@ -366,3 +368,35 @@ func TestMultipleEvaluations(t *testing.T) {
 		t.Error("It should be greater than 0")
 	}
 }
+
+func TestEvaluatorWithOverrides(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	forced := overrides.NewStore()
+	forced.Set("mysplittest3", "on", nil, "test")
+	forced.Set("mysplittest5", "forced", nil)
+
+	evaluator := NewEvaluator(
+		&mockStorage{},
+		nil,
+		nil,
+		logger)
+	evaluator.SetOverrides(forced)
+
+	key := "test"
+	result := evaluator.EvaluateFeatures(key, &key, []string{"mysplittest3", "mysplittest5"}, nil)
+
+	if result.Evaluations["mysplittest3"].Treatment != "on" || result.Evaluations["mysplittest3"].Label != impressionlabels.Override {
+		t.Error("Override should take precedence over killed splits")
+	}
+	if result.Evaluations["mysplittest3"].SplitChangeNumber != mysplittest3.ChangeNumber {
+		t.Error("Change number of the stored split should be kept")
+	}
+	if result.Evaluations["mysplittest5"].Treatment != "forced" {
+		t.Error("Override should be applied to splits not in storage")
+	}
+
+	other := "other"
+	if evaluator.EvaluateFeature(other, &other, "mysplittest3", nil).Treatment != "killed" {
+		t.Error("Override should only be applied to the given keys")
+	}
+}
@@ -20,3 +20,6 @@ const Exception = "exception"
 
 // ClientNotReady label will be returned when the client is not ready
 const ClientNotReady = "not ready"
+
+// Override label will be returned when the treatment has been forced through the factory overrides
+const Override = "override"
@@ -0,0 +1,10 @@
+package eventlistener
+
+import (
+	"github.com/splitio/go-split-commons/dtos"
+)
+
+// EventListener declaration of EventListener interface. It's notified each time an event is tracked
+type EventListener interface {
+	LogEvent(data dtos.EventDTO)
+}
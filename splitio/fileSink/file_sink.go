@@ -0,0 +1,308 @@
+// Package filesink provides a listener that keeps a local copy of impressions and events as JSON lines
+package filesink
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const (
+	// RecordTypeImpression identifies lines holding an impression
+	RecordTypeImpression = "impression"
+	// RecordTypeEvent identifies lines holding a tracked event
+	RecordTypeEvent = "event"
+)
+
+const (
+	defaultPrefix = "splitio"
+	fileExtension = ".jsonl"
+	gzipExtension = ".gz"
+)
+
+// Options struct used to set up the file sink
+// - Directory - (Required) Directory where files are written. It's created if it doesn't exist
+// - Prefix - Prefix used when naming files. Defaults to "splitio"
+// - MaxSize - Size in bytes after which the current file is rotated. When 0, files are not rotated by size
+// - MaxAge - Time in seconds after which the current file is rotated. When 0, files are not rotated by time
+// - Compress - Whether files should be gzipped once they're rotated or the sink is closed
+type Options struct {
+	Directory string
+	Prefix    string
+	MaxSize   int64
+	MaxAge    int
+	Compress  bool
+}
+
+// Record struct written as a single JSON line for each impression or event
+type Record struct {
+	Type               string                 `json:"type"`
+	Impression         *dtos.Impression       `json:"impression,omitempty"`
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
+	InstanceID         string                 `json:"instanceId,omitempty"`
+	SDKLanguageVersion string                 `json:"sdkLanguageVersion,omitempty"`
+	Properties         map[string]interface{} `json:"properties,omitempty"`
+	Event              *dtos.EventDTO         `json:"event,omitempty"`
+}
+
+// FileSink writes impressions and events to rotating JSONL files. It implements both
+// impressionlistener.ImpressionListener and eventlistener.EventListener. Rotated files are compressed in the
+// background, so writes are not held up by them
+type FileSink struct {
+	options      Options
+	logger       logging.LoggerInterface
+	file         *os.File
+	size         int64
+	openedAt     time.Time
+	sequence     int
+	closed       bool
+	mutex        sync.Mutex
+	now          func() time.Time
+	compressions sync.WaitGroup
+	compressErr  error
+	errMutex     sync.Mutex
+}
+
+// NewFileSink instantiates a new FileSink writing to the configured directory
+func NewFileSink(options Options, logger logging.LoggerInterface) (*FileSink, error) {
+	if options.Directory == "" {
+		return nil, errors.New("File sink: Directory must be set")
+	}
+	if options.MaxSize < 0 {
+		return nil, fmt.Errorf("File sink: MaxSize must be >= 0. Actual is: %d", options.MaxSize)
+	}
+	if options.MaxAge < 0 {
+		return nil, fmt.Errorf("File sink: MaxAge must be >= 0. Actual is: %d", options.MaxAge)
+	}
+	if options.Prefix == "" {
+		options.Prefix = defaultPrefix
+	}
+	if logger == nil {
+		logger = logging.NewLogger(nil)
+	}
+
+	err := os.MkdirAll(options.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		options: options,
+		logger:  logger,
+		now:     time.Now,
+	}, nil
+}
+
+// LogImpression writes an impression to the current file
+func (s *FileSink) LogImpression(data impressionlistener.ILObject) {
+	impression := data.Impression
+	s.write(Record{
+		Type:               RecordTypeImpression,
+		Impression:         &impression,
+		Attributes:         data.Attributes,
+		InstanceID:         data.InstanceID,
+		SDKLanguageVersion: data.SDKLanguageVersion,
+		Properties:         data.Properties,
+	})
+}
+
+// LogImpressions writes a batch of impressions to the current file
+func (s *FileSink) LogImpressions(data []impressionlistener.ILObject) {
+	for _, object := range data {
+		s.LogImpression(object)
+	}
+}
+
+// LogEvent writes a tracked event to the current file
+func (s *FileSink) LogEvent(data dtos.EventDTO) {
+	s.write(Record{Type: RecordTypeEvent, Event: &data})
+}
+
+// write serializes a record and appends it to the current file, rotating it first if needed
+func (s *FileSink) write(record Record) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		s.logger.Error("File sink: error serializing record", err.Error())
+		return
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		s.logger.Warning("File sink: record received after closing the sink was discarded")
+		return
+	}
+
+	if s.file != nil && s.shouldRotate(int64(len(line))) {
+		err = s.closeCurrent()
+		if err != nil {
+			s.logger.Error("File sink: error rotating file", err.Error())
+		}
+	}
+
+	if s.file == nil {
+		err = s.open()
+		if err != nil {
+			s.logger.Error("File sink: error opening file", err.Error())
+			return
+		}
+	}
+
+	written, err := s.file.Write(line)
+	s.size += int64(written)
+	if err != nil {
+		s.logger.Error("File sink: error writing record", err.Error())
+	}
+}
+
+// shouldRotate returns true if the current file has exceeded its size or age
+func (s *FileSink) shouldRotate(incoming int64) bool {
+	if s.options.MaxSize > 0 && s.size > 0 && s.size+incoming > s.options.MaxSize {
+		return true
+	}
+	if s.options.MaxAge > 0 && s.now().Sub(s.openedAt) >= time.Duration(s.options.MaxAge)*time.Second {
+		return true
+	}
+	return false
+}
+
+// open creates a new file named after the current time
+func (s *FileSink) open() error {
+	s.sequence++
+	name := fmt.Sprintf("%s-%s-%d%s", s.options.Prefix, s.now().UTC().Format("20060102T150405"), s.sequence, fileExtension)
+	file, err := os.OpenFile(filepath.Join(s.options.Directory, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.size = 0
+	s.openedAt = s.now()
+	return nil
+}
+
+// closeCurrent syncs and closes the current file, compressing it in the background if required
+func (s *FileSink) closeCurrent() error {
+	if s.file == nil {
+		return nil
+	}
+	file := s.file
+	s.file = nil
+
+	err := file.Sync()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.options.Compress {
+		s.compressions.Add(1)
+		go s.compress(file.Name())
+	}
+	return nil
+}
+
+// compress gzips a rotated file, keeping the first error so that it's returned by Close
+func (s *FileSink) compress(name string) {
+	defer s.compressions.Done()
+	err := compress(name)
+	if err == nil {
+		return
+	}
+	s.logger.Error("File sink: error compressing file", err.Error())
+	s.errMutex.Lock()
+	defer s.errMutex.Unlock()
+	if s.compressErr == nil {
+		s.compressErr = err
+	}
+}
+
+// Sync flushes the current file to stable storage
+func (s *FileSink) Sync() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// Close syncs and closes the current file and waits for pending compressions. Records received afterwards are
+// discarded
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.closeCurrent()
+	s.mutex.Unlock()
+
+	s.compressions.Wait()
+	s.errMutex.Lock()
+	defer s.errMutex.Unlock()
+	if err == nil {
+		err = s.compressErr
+	}
+	return err
+}
+
+// compress gzips a file and removes the original one. Existing archives are never overwritten: if the archive
+// name is taken, a numeric suffix is added to it
+func compress(name string) error {
+	source, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := createArchive(name)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(target)
+	_, err = io.Copy(writer, source)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if syncErr := target.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target.Name())
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// createArchive creates the archive for a file, named after it with the gzip extension, or with a numeric
+// suffix if that name is taken
+func createArchive(name string) (*os.File, error) {
+	archive := name + gzipExtension
+	for suffix := 1; ; suffix++ {
+		file, err := os.OpenFile(archive, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if !os.IsExist(err) {
+			return file, err
+		}
+		archive = fmt.Sprintf("%s.%d%s", name, suffix, gzipExtension)
+	}
+}
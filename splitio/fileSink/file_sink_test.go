@@ -0,0 +1,221 @@
+package filesink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func impression(feature string) impressionlistener.ILObject {
+	return impressionlistener.ILObject{
+		Impression:         dtos.Impression{FeatureName: feature, KeyName: "user1", Treatment: "on", Label: "default rule"},
+		Attributes:         map[string]interface{}{"one": "test"},
+		InstanceID:         "machine",
+		SDKLanguageVersion: "go-test",
+	}
+}
+
+func files(t *testing.T, dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readRecords(t *testing.T, name string) []Record {
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(name, gzipExtension) {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	records := make([]Record, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal("Every line should be valid JSON", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestFileSinkWritesRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, err := NewFileSink(Options{Directory: dir}, logging.NewLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.LogImpression(impression("f1"))
+	sink.LogEvent(dtos.EventDTO{Key: "user1", TrafficTypeName: "user", EventTypeID: "checkout", Value: 10.5})
+	if err := sink.Sync(); err != nil {
+		t.Error("Sync should not fail", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Error("Close should not fail", err)
+	}
+	sink.LogImpression(impression("f2"))
+
+	names := files(t, dir)
+	if len(names) != 1 || !strings.HasSuffix(names[0], fileExtension) {
+		t.Error("A single uncompressed file should be written. Actual:", names)
+		return
+	}
+	records := readRecords(t, names[0])
+	if len(records) != 2 {
+		t.Error("Records written after closing should be discarded. Actual:", len(records))
+		return
+	}
+	if records[0].Type != RecordTypeImpression || records[0].Impression.FeatureName != "f1" || records[0].Attributes["one"] != "test" || records[0].InstanceID != "machine" {
+		t.Error("Unexpected impression record", records[0])
+	}
+	if records[1].Type != RecordTypeEvent || records[1].Event.EventTypeID != "checkout" || records[1].Event.Value != 10.5 {
+		t.Error("Unexpected event record", records[1])
+	}
+}
+
+func TestFileSinkRotatesBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, _ := NewFileSink(Options{Directory: dir, Prefix: "audit", MaxSize: 1}, logging.NewLogger(nil))
+	sink.LogImpressions([]impressionlistener.ILObject{impression("f1"), impression("f2"), impression("f3")})
+	sink.Close()
+
+	names := files(t, dir)
+	if len(names) != 3 {
+		t.Error("Every record exceeding the size should go to a new file. Actual:", names)
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(filepath.Base(name), "audit-") {
+			t.Error("Files should be named after the prefix", name)
+		}
+		if len(readRecords(t, name)) != 1 {
+			t.Error("A file should be rotated before exceeding its size", name)
+		}
+	}
+}
+
+func TestFileSinkRotatesByTimeAndCompresses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, _ := NewFileSink(Options{Directory: dir, MaxAge: 60, Compress: true}, logging.NewLogger(nil))
+	current := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return current }
+
+	sink.LogImpression(impression("f1"))
+	sink.LogImpression(impression("f2"))
+	current = current.Add(time.Minute)
+	sink.LogImpression(impression("f3"))
+	sink.compressions.Wait()
+
+	names := files(t, dir)
+	if len(names) != 2 || !strings.HasSuffix(names[0], fileExtension+gzipExtension) || !strings.HasSuffix(names[1], fileExtension) {
+		t.Error("The expired file should be rotated and compressed. Actual:", names)
+		return
+	}
+	if len(readRecords(t, names[0])) != 2 {
+		t.Error("Compressed file should hold the records written before rotating")
+	}
+
+	sink.Close()
+	names = files(t, dir)
+	if len(names) != 2 || !strings.HasSuffix(names[1], fileExtension+gzipExtension) {
+		t.Error("The current file should be compressed when closing. Actual:", names)
+		return
+	}
+	records := readRecords(t, names[1])
+	if len(records) != 1 || records[0].Impression.FeatureName != "f3" {
+		t.Error("Unexpected records", records)
+	}
+}
+
+func TestFileSinkKeepsExistingArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, _ := NewFileSink(Options{Directory: dir, Compress: true}, logging.NewLogger(nil))
+	sink.now = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+	existing := filepath.Join(dir, "splitio-20200101T000000-1"+fileExtension+gzipExtension)
+	if err := ioutil.WriteFile(existing, []byte("previous archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sink.LogImpression(impression("f1"))
+	if err := sink.Close(); err != nil {
+		t.Error("Close should not fail", err)
+	}
+
+	if content, _ := ioutil.ReadFile(existing); string(content) != "previous archive" {
+		t.Error("Existing archives should not be overwritten")
+	}
+	archive := strings.TrimSuffix(existing, gzipExtension) + ".1" + gzipExtension
+	if names := files(t, dir); len(names) != 2 || names[0] != archive {
+		t.Error("The archive should be given a new name. Actual:", names)
+		return
+	}
+	if records := readRecords(t, archive); len(records) != 1 || records[0].Impression.FeatureName != "f1" {
+		t.Error("Unexpected records", records)
+	}
+}
+
+func TestFileSinkValidation(t *testing.T) {
+	_, err := NewFileSink(Options{}, nil)
+	if err == nil || err.Error() != "File sink: Directory must be set" {
+		t.Error("An error should be returned when no directory is set")
+	}
+
+	_, err = NewFileSink(Options{Directory: os.TempDir(), MaxSize: -1}, nil)
+	if err == nil || err.Error() != "File sink: MaxSize must be >= 0. Actual is: -1" {
+		t.Error("An error should be returned for a negative size")
+	}
+
+	_, err = NewFileSink(Options{Directory: os.TempDir(), MaxAge: -1}, nil)
+	if err == nil || err.Error() != "File sink: MaxAge must be >= 0. Actual is: -1" {
+		t.Error("An error should be returned for a negative age")
+	}
+}
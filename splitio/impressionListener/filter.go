@@ -0,0 +1,79 @@
+package impressionlistener
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+)
+
+// Filter struct used to decide which impressions are handed to a listener. Empty fields match everything
+// - Features - Feature name patterns, using the same syntax as path.Match (ie: "checkout_*")
+// - Treatments - Treatments the impression must have
+// - Labels - Labels the impression must have
+// - SamplingRate - Fraction of the matching impressions that will be kept, between 0 and 1. 0 drops them all and
+// nil keeps them all. Use the SampleRate helper to set it
+type Filter struct {
+	Features     []string
+	Treatments   []string
+	Labels       []string
+	SamplingRate *float64
+}
+
+// SampleRate returns a pointer to rate, to be used as the SamplingRate of a Filter
+func SampleRate(rate float64) *float64 {
+	return &rate
+}
+
+// FilteredListener binds an impression listener with the filter deciding which impressions it receives
+type FilteredListener struct {
+	Listener ImpressionListener
+	Filter   Filter
+}
+
+// Validate checks that the patterns and the sampling rate of the filter are well formed
+func (f *Filter) Validate() error {
+	for _, pattern := range f.Features {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Impression listener filter: invalid feature pattern \"%s\"", pattern)
+		}
+	}
+	if f.SamplingRate != nil && (*f.SamplingRate < 0 || *f.SamplingRate > 1) {
+		return fmt.Errorf("Impression listener filter: SamplingRate must be between 0 and 1. Actual is: %v", *f.SamplingRate)
+	}
+	return nil
+}
+
+// Matches returns true if the impression should be handed to the listener
+func (f *Filter) Matches(data ILObject) bool {
+	if len(f.Features) > 0 && !matchesAnyPattern(f.Features, data.Impression.FeatureName) {
+		return false
+	}
+	if len(f.Treatments) > 0 && !contains(f.Treatments, data.Impression.Treatment) {
+		return false
+	}
+	if len(f.Labels) > 0 && !contains(f.Labels, data.Impression.Label) {
+		return false
+	}
+	if f.SamplingRate != nil && *f.SamplingRate < 1 {
+		return rand.Float64() < *f.SamplingRate
+	}
+	return true
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
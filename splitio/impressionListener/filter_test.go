@@ -0,0 +1,77 @@
+package impressionlistener
+
+import (
+	"testing"
+
+	"github.com/splitio/go-split-commons/dtos"
+)
+
+func TestFilterMatches(t *testing.T) {
+	data := ILObject{Impression: dtos.Impression{FeatureName: "checkout_button", Treatment: "on", Label: "default rule"}}
+
+	filter := Filter{}
+	if !filter.Matches(data) {
+		t.Error("Empty filter should match every impression")
+	}
+
+	filter = Filter{Features: []string{"search_*", "checkout_*"}}
+	if !filter.Matches(data) {
+		t.Error("Feature pattern should match")
+	}
+
+	filter = Filter{Features: []string{"search_*"}}
+	if filter.Matches(data) {
+		t.Error("Feature pattern should not match")
+	}
+
+	filter = Filter{Treatments: []string{"off"}}
+	if filter.Matches(data) {
+		t.Error("Treatment should not match")
+	}
+
+	filter = Filter{Treatments: []string{"off", "on"}, Labels: []string{"default rule"}}
+	if !filter.Matches(data) {
+		t.Error("Treatment and label should match")
+	}
+
+	filter = Filter{Labels: []string{"killed"}}
+	if filter.Matches(data) {
+		t.Error("Label should not match")
+	}
+
+	filter = Filter{SamplingRate: SampleRate(0.5)}
+	matched := 0
+	for i := 0; i < 10000; i++ {
+		if filter.Matches(data) {
+			matched++
+		}
+	}
+	if matched < 4000 || matched > 6000 {
+		t.Error("Roughly half of the impressions should be sampled. Actual:", matched)
+	}
+
+	filter = Filter{SamplingRate: SampleRate(0)}
+	for i := 0; i < 100; i++ {
+		if filter.Matches(data) {
+			t.Error("A sampling rate of 0 should drop every impression")
+			break
+		}
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	filter := Filter{Features: []string{"checkout_*"}, SamplingRate: SampleRate(1)}
+	if filter.Validate() != nil {
+		t.Error("It should be valid")
+	}
+
+	filter = Filter{Features: []string{"checkout_["}}
+	if err := filter.Validate(); err == nil || err.Error() != "Impression listener filter: invalid feature pattern \"checkout_[\"" {
+		t.Error("It should return err")
+	}
+
+	filter = Filter{SamplingRate: SampleRate(1.5)}
+	if err := filter.Validate(); err == nil || err.Error() != "Impression listener filter: SamplingRate must be between 0 and 1. Actual is: 1.5" {
+		t.Error("It should return err")
+	}
+}
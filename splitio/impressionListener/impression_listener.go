@@ -4,3 +4,10 @@ package impressionlistener
 type ImpressionListener interface {
 	LogImpression(data ILObject)
 }
+
+// BatchImpressionListener declaration of an ImpressionListener able to receive impressions in bulk.
+// When the configured listener implements it, impressions are buffered and delivered through LogImpressions
+type BatchImpressionListener interface {
+	ImpressionListener
+	LogImpressions(data []ILObject)
+}
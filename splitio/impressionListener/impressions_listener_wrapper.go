@@ -1,7 +1,29 @@
 package impressionlistener
 
 import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const (
+	// OverflowPolicyDropOldest discards the oldest queued impression to make room for the new one
+	OverflowPolicyDropOldest = "drop_oldest"
+	// OverflowPolicyDropNewest discards the impression being queued when the queue is full
+	OverflowPolicyDropNewest = "drop_newest"
+	// OverflowPolicyBlock blocks the caller until there is room in the queue
+	OverflowPolicyBlock = "block"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5
 )
 
 // ILObject struct to map entire data for listener
@@ -10,32 +32,384 @@ type ILObject struct {
 	Attributes         map[string]interface{}
 	InstanceID         string
 	SDKLanguageVersion string
+	Properties         map[string]interface{}
+}
+
+// Options struct used to set up how impressions are dispatched to the listener
+// - QueueSize - How many impressions can be waiting for the listener. When 0, the listener is called synchronously
+// - Workers - How many goroutines will be delivering queued impressions to the listener
+// - OverflowPolicy - What to do with new impressions when the queue is full <'drop_oldest'|'drop_newest'|'block'>
+// - BatchSize - How many impressions are handed at once to a BatchImpressionListener
+// - FlushInterval - How often (in seconds) buffered impressions are handed to a BatchImpressionListener
+type Options struct {
+	QueueSize      int
+	Workers        int
+	OverflowPolicy string
+	BatchSize      int
+	FlushInterval  int
+}
+
+// listenerEntry holds a registered listener along with its filter and pending batch
+type listenerEntry struct {
+	listener      ImpressionListener
+	batchListener BatchImpressionListener
+	filter        Filter
+	buffer        []ILObject
+	mutex         sync.Mutex
 }
 
 // WrapperImpressionListener struct
 type WrapperImpressionListener struct {
 	ImpressionListener ImpressionListener
+	entries            []*listenerEntry
 	metadata           dtos.Metadata
+	options            Options
+	logger             logging.LoggerInterface
+	queue              chan ILObject
+	dropped            int64
+	stopped            bool
+	stopping           chan struct{}
+	stopOnce           sync.Once
+	mutex              sync.RWMutex
+	workers            sync.WaitGroup
+	stopFlusher        chan struct{}
+	pending            int
+	idle               chan struct{}
+	pendingMutex       sync.Mutex
 }
 
-// NewImpressionListenerWrapper instantiates a new ImpressionListenerWrapper
+// NewImpressionListenerWrapper instantiates a new ImpressionListenerWrapper that calls the listener synchronously.
+// No background goroutine is started, so a BatchImpressionListener only receives full batches and the impressions
+// buffered when Flush or Stop are called
 func NewImpressionListenerWrapper(impressionListener ImpressionListener, metadata dtos.Metadata) *WrapperImpressionListener {
-	return &WrapperImpressionListener{
-		ImpressionListener: impressionListener,
-		metadata:           metadata,
+	return newWrapper([]FilteredListener{{Listener: impressionListener}}, metadata, Options{}, logging.NewLogger(nil), false)
+}
+
+// NewImpressionListenerWrapperWithOptions instantiates a new ImpressionListenerWrapper. If a queue size is set,
+// impressions are delivered to the listener in background by the configured number of workers. If the listener
+// implements BatchImpressionListener, impressions are buffered and handed in batches
+func NewImpressionListenerWrapperWithOptions(
+	impressionListener ImpressionListener,
+	metadata dtos.Metadata,
+	options Options,
+	logger logging.LoggerInterface,
+) *WrapperImpressionListener {
+	return NewMultiImpressionListenerWrapper(
+		[]FilteredListener{{Listener: impressionListener}},
+		metadata,
+		options,
+		logger,
+	)
+}
+
+// NewMultiImpressionListenerWrapper instantiates a new ImpressionListenerWrapper that fans impressions out to
+// several listeners, each one receiving only the impressions matching its filter. Stop must be called to release
+// the goroutines delivering queued impressions and flushing batches
+func NewMultiImpressionListenerWrapper(
+	listeners []FilteredListener,
+	metadata dtos.Metadata,
+	options Options,
+	logger logging.LoggerInterface,
+) *WrapperImpressionListener {
+	return newWrapper(listeners, metadata, options, logger, true)
+}
+
+// newWrapper builds the wrapper. The periodic batch flusher is only started when withFlusher is set
+func newWrapper(
+	listeners []FilteredListener,
+	metadata dtos.Metadata,
+	options Options,
+	logger logging.LoggerInterface,
+	withFlusher bool,
+) *WrapperImpressionListener {
+	wrapper := &WrapperImpressionListener{
+		entries:  make([]*listenerEntry, 0, len(listeners)),
+		metadata: metadata,
+		options:  options,
+		logger:   logger,
+		idle:     make(chan struct{}),
+		stopping: make(chan struct{}),
+	}
+	close(wrapper.idle)
+
+	if wrapper.options.BatchSize <= 0 {
+		wrapper.options.BatchSize = defaultBatchSize
+	}
+	if wrapper.options.FlushInterval <= 0 {
+		wrapper.options.FlushInterval = defaultFlushInterval
 	}
+
+	hasBatchListeners := false
+	for _, filtered := range listeners {
+		if filtered.Listener == nil {
+			continue
+		}
+		entry := &listenerEntry{
+			listener: filtered.Listener,
+			filter:   filtered.Filter,
+		}
+		if batchListener, ok := filtered.Listener.(BatchImpressionListener); ok {
+			entry.batchListener = batchListener
+			entry.buffer = make([]ILObject, 0, wrapper.options.BatchSize)
+			hasBatchListeners = true
+		}
+		wrapper.entries = append(wrapper.entries, entry)
+	}
+
+	if len(wrapper.entries) == 1 {
+		wrapper.ImpressionListener = wrapper.entries[0].listener
+	}
+
+	if hasBatchListeners && withFlusher {
+		wrapper.stopFlusher = make(chan struct{})
+		wrapper.workers.Add(1)
+		go wrapper.flusher()
+	}
+
+	if options.QueueSize > 0 {
+		if wrapper.options.Workers <= 0 {
+			wrapper.options.Workers = 1
+		}
+		wrapper.queue = make(chan ILObject, options.QueueSize)
+		for w := 0; w < wrapper.options.Workers; w++ {
+			wrapper.workers.Add(1)
+			go wrapper.worker()
+		}
+	}
+
+	return wrapper
 }
 
 // SendDataToClient sends the data to client
 func (i *WrapperImpressionListener) SendDataToClient(impressions []dtos.Impression, attributes map[string]interface{}) {
+	i.SendDataToClientWithProperties(impressions, attributes, nil)
+}
+
+// SendDataToClientWithProperties sends the data to client along with the properties attached to the evaluation
+func (i *WrapperImpressionListener) SendDataToClientWithProperties(
+	impressions []dtos.Impression,
+	attributes map[string]interface{},
+	properties map[string]interface{},
+) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
 	for _, impression := range impressions {
 		datToSend := ILObject{
 			Impression:         impression,
 			Attributes:         attributes,
 			InstanceID:         i.metadata.MachineName,
 			SDKLanguageVersion: i.metadata.SDKVersion,
+			Properties:         properties,
+		}
+
+		if i.stopped {
+			atomic.AddInt64(&i.dropped, 1)
+			continue
+		}
+
+		if i.queue == nil {
+			i.deliver(datToSend)
+			continue
+		}
+
+		i.enqueue(datToSend)
+	}
+}
+
+// enqueue adds an impression to the queue, applying the overflow policy if it's full
+func (i *WrapperImpressionListener) enqueue(data ILObject) {
+	i.addPending()
+	switch i.options.OverflowPolicy {
+	case OverflowPolicyBlock:
+		// Stop waits for the senders to release the lock, so a blocked one gives up once stopping is closed
+		select {
+		case i.queue <- data:
+		case <-i.stopping:
+			atomic.AddInt64(&i.dropped, 1)
+			i.donePending()
+		}
+	case OverflowPolicyDropOldest:
+		for {
+			select {
+			case i.queue <- data:
+				return
+			default:
+			}
+			select {
+			case <-i.queue:
+				atomic.AddInt64(&i.dropped, 1)
+				i.donePending()
+			default:
+			}
+		}
+	default:
+		select {
+		case i.queue <- data:
+		default:
+			atomic.AddInt64(&i.dropped, 1)
+			i.donePending()
+		}
+	}
+}
+
+// addPending accounts for an impression entering the queue
+func (i *WrapperImpressionListener) addPending() {
+	i.pendingMutex.Lock()
+	defer i.pendingMutex.Unlock()
+	if i.pending == 0 {
+		i.idle = make(chan struct{})
+	}
+	i.pending++
+}
+
+// donePending accounts for a queued impression that was delivered or dropped
+func (i *WrapperImpressionListener) donePending() {
+	i.pendingMutex.Lock()
+	defer i.pendingMutex.Unlock()
+	i.pending--
+	if i.pending == 0 {
+		close(i.idle)
+	}
+}
+
+// worker delivers queued impressions to the listeners until the queue is closed
+func (i *WrapperImpressionListener) worker() {
+	defer i.workers.Done()
+	for data := range i.queue {
+		i.deliver(data)
+		i.donePending()
+	}
+}
+
+// flusher periodically hands buffered impressions to the batch listeners until the wrapper is stopped
+func (i *WrapperImpressionListener) flusher() {
+	defer i.workers.Done()
+	ticker := time.NewTicker(time.Duration(i.options.FlushInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			i.flush()
+		case <-i.stopFlusher:
+			return
 		}
+	}
+}
+
+// protect runs fn guarding the caller against a panicking listener
+func (i *WrapperImpressionListener) protect(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			i.logger.Error(
+				"Impression listener is panicking with the following error", r, "\n",
+				string(debug.Stack()), "\n",
+			)
+		}
+	}()
+	fn()
+}
+
+// deliver hands an impression to every listener whose filter matches it
+func (i *WrapperImpressionListener) deliver(data ILObject) {
+	for _, entry := range i.entries {
+		if !entry.filter.Matches(data) {
+			continue
+		}
+		i.protect(func() { entry.deliver(data, i.options.BatchSize) })
+	}
+}
+
+// flush hands every buffered impression to the batch listeners
+func (i *WrapperImpressionListener) flush() {
+	for _, entry := range i.entries {
+		if entry.batchListener != nil {
+			i.protect(func() { entry.flush(i.options.BatchSize) })
+		}
+	}
+}
+
+// deliver hands an impression to the listener, buffering it when the listener accepts batches
+func (e *listenerEntry) deliver(data ILObject, batchSize int) {
+	if e.batchListener == nil {
+		e.listener.LogImpression(data)
+		return
+	}
+
+	e.mutex.Lock()
+	e.buffer = append(e.buffer, data)
+	if len(e.buffer) < batchSize {
+		e.mutex.Unlock()
+		return
+	}
+	batch := e.buffer
+	e.buffer = make([]ILObject, 0, batchSize)
+	e.mutex.Unlock()
+
+	e.batchListener.LogImpressions(batch)
+}
+
+// flush hands every buffered impression to the batch listener
+func (e *listenerEntry) flush(batchSize int) {
+	e.mutex.Lock()
+	if len(e.buffer) == 0 {
+		e.mutex.Unlock()
+		return
+	}
+	batch := e.buffer
+	e.buffer = make([]ILObject, 0, batchSize)
+	e.mutex.Unlock()
+
+	e.batchListener.LogImpressions(batch)
+}
+
+// Flush hands the impressions buffered for batch listeners without waiting for the flush interval. Impressions
+// still waiting in the queue are not included, use Drain to wait for them first
+func (i *WrapperImpressionListener) Flush() {
+	i.flush()
+}
+
+// Drain blocks until every impression queued so far has been handed to the listeners, or until ctx is done, in
+// which case it returns the context error
+func (i *WrapperImpressionListener) Drain(ctx context.Context) error {
+	i.pendingMutex.Lock()
+	idle := i.idle
+	i.pendingMutex.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of impressions that were discarded before reaching the listener
+func (i *WrapperImpressionListener) Dropped() int64 {
+	return atomic.LoadInt64(&i.dropped)
+}
+
+// Stop stops accepting new impressions and blocks until the queued ones have been delivered to the listener.
+// Senders blocked on a full queue under the block policy drop their impressions instead of holding Stop back
+func (i *WrapperImpressionListener) Stop() {
+	i.stopOnce.Do(func() { close(i.stopping) })
+	i.mutex.Lock()
+	if i.stopped {
+		i.mutex.Unlock()
+		return
+	}
+	i.stopped = true
+	if i.queue != nil {
+		close(i.queue)
+	}
+	if i.stopFlusher != nil {
+		close(i.stopFlusher)
+	}
+	i.mutex.Unlock()
 
-		i.ImpressionListener.LogImpression(datToSend)
+	i.workers.Wait()
+	i.flush()
+	if dropped := i.Dropped(); dropped > 0 {
+		i.logger.Warning(fmt.Sprintf("Impression listener: %d impressions were dropped before reaching the listener", dropped))
 	}
 }
@@ -0,0 +1,344 @@
+package impressionlistener
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+type listenerMock struct {
+	mutex       sync.Mutex
+	impressions []ILObject
+	release     chan struct{}
+	calls       int64
+}
+
+func (l *listenerMock) LogImpression(data ILObject) {
+	atomic.AddInt64(&l.calls, 1)
+	if l.release != nil {
+		<-l.release
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.impressions = append(l.impressions, data)
+}
+
+func (l *listenerMock) features() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	features := make([]string, 0, len(l.impressions))
+	for _, data := range l.impressions {
+		features = append(features, data.Impression.FeatureName)
+	}
+	return features
+}
+
+type panickingListener struct{}
+
+func (l *panickingListener) LogImpression(data ILObject) {
+	panic("listener error")
+}
+
+func impressionsFor(features ...string) []dtos.Impression {
+	impressions := make([]dtos.Impression, 0, len(features))
+	for _, feature := range features {
+		impressions = append(impressions, dtos.Impression{FeatureName: feature, KeyName: "user1", Treatment: "on"})
+	}
+	return impressions
+}
+
+var metadata = dtos.Metadata{SDKVersion: "go-test", MachineName: "machine"}
+
+func TestSynchronousWrapper(t *testing.T) {
+	listener := &listenerMock{}
+	wrapper := NewImpressionListenerWrapper(listener, metadata)
+
+	wrapper.SendDataToClient(impressionsFor("f1", "f2"), map[string]interface{}{"one": 1})
+	if len(listener.impressions) != 2 {
+		t.Error("Impressions should be delivered before SendDataToClient returns")
+	}
+	if listener.impressions[0].InstanceID != "machine" || listener.impressions[0].SDKLanguageVersion != "go-test" {
+		t.Error("Metadata should be attached to impressions")
+	}
+	if listener.impressions[0].Attributes["one"] != 1 {
+		t.Error("Attributes should be attached to impressions")
+	}
+
+	wrapper.Stop()
+	wrapper.SendDataToClient(impressionsFor("f3"), nil)
+	if len(listener.impressions) != 2 || wrapper.Dropped() != 1 {
+		t.Error("Impressions sent after stopping should be dropped")
+	}
+}
+
+func TestAsyncWrapperDrainsOnStop(t *testing.T) {
+	listener := &listenerMock{}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{QueueSize: 100, Workers: 3}, logging.NewLogger(nil))
+
+	for i := 0; i < 10; i++ {
+		wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+	}
+	wrapper.Stop()
+
+	if len(listener.features()) != 30 {
+		t.Error("All queued impressions should be delivered when stopping. Delivered:", len(listener.features()))
+	}
+	if wrapper.Dropped() != 0 {
+		t.Error("No impressions should be dropped")
+	}
+}
+
+func TestAsyncWrapperDrain(t *testing.T) {
+	listener := &listenerMock{release: make(chan struct{})}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{QueueSize: 10, Workers: 1}, logging.NewLogger(nil))
+	defer wrapper.Stop()
+
+	if wrapper.Drain(context.Background()) != nil {
+		t.Error("Draining an empty queue should not block")
+	}
+
+	wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if wrapper.Drain(ctx) != context.DeadlineExceeded {
+		t.Error("Drain should return when the context is done")
+	}
+
+	close(listener.release)
+	if wrapper.Drain(context.Background()) != nil || len(listener.features()) != 3 {
+		t.Error("Drain should wait for every queued impression to be delivered")
+	}
+}
+
+func TestAsyncWrapperDropNewest(t *testing.T) {
+	listener := &listenerMock{release: make(chan struct{})}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{
+		QueueSize:      2,
+		Workers:        1,
+		OverflowPolicy: OverflowPolicyDropNewest,
+	}, logging.NewLogger(nil))
+
+	wrapper.SendDataToClient(impressionsFor("f1"), nil)
+	for atomic.LoadInt64(&listener.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	wrapper.SendDataToClient(impressionsFor("f2", "f3", "f4", "f5"), nil)
+	close(listener.release)
+	wrapper.Stop()
+
+	features := listener.features()
+	if len(features) != 3 || features[0] != "f1" || features[1] != "f2" || features[2] != "f3" {
+		t.Error("Newest impressions should be dropped. Delivered:", features)
+	}
+	if wrapper.Dropped() != 2 {
+		t.Error("Two impressions should be dropped. Actual:", wrapper.Dropped())
+	}
+}
+
+func TestAsyncWrapperDropOldest(t *testing.T) {
+	listener := &listenerMock{release: make(chan struct{})}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{
+		QueueSize:      2,
+		Workers:        1,
+		OverflowPolicy: OverflowPolicyDropOldest,
+	}, logging.NewLogger(nil))
+
+	wrapper.SendDataToClient(impressionsFor("f1"), nil)
+	for atomic.LoadInt64(&listener.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	wrapper.SendDataToClient(impressionsFor("f2", "f3", "f4", "f5"), nil)
+	close(listener.release)
+	wrapper.Stop()
+
+	features := listener.features()
+	if len(features) != 3 || features[0] != "f1" || features[1] != "f4" || features[2] != "f5" {
+		t.Error("Oldest impressions should be dropped. Delivered:", features)
+	}
+	if wrapper.Dropped() != 2 {
+		t.Error("Two impressions should be dropped. Actual:", wrapper.Dropped())
+	}
+}
+
+func TestAsyncWrapperBlock(t *testing.T) {
+	listener := &listenerMock{release: make(chan struct{})}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{
+		QueueSize:      1,
+		Workers:        1,
+		OverflowPolicy: OverflowPolicyBlock,
+	}, logging.NewLogger(nil))
+
+	done := make(chan struct{})
+	go func() {
+		wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Error("SendDataToClient should block while the queue is full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(listener.release)
+	<-done
+	wrapper.Stop()
+
+	if len(listener.features()) != 3 || wrapper.Dropped() != 0 {
+		t.Error("No impressions should be dropped when blocking")
+	}
+}
+
+func TestAsyncWrapperBlockedSenderDoesNotHoldStop(t *testing.T) {
+	listener := &listenerMock{release: make(chan struct{})}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{
+		QueueSize:      1,
+		Workers:        1,
+		OverflowPolicy: OverflowPolicyBlock,
+	}, logging.NewLogger(nil))
+
+	done := make(chan struct{})
+	go func() {
+		wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		wrapper.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("A sender blocked on a full queue should give up once Stop is called")
+	}
+
+	close(listener.release)
+	<-stopped
+	if wrapper.Dropped() != 1 || len(listener.features()) != 2 {
+		t.Error("The impression of the blocked sender should be dropped", listener.features(), wrapper.Dropped())
+	}
+}
+
+func TestAsyncWrapperPanickingListener(t *testing.T) {
+	wrapper := NewImpressionListenerWrapperWithOptions(&panickingListener{}, metadata, Options{QueueSize: 10, Workers: 1}, logging.NewLogger(nil))
+	wrapper.SendDataToClient(impressionsFor("f1", "f2"), nil)
+	wrapper.Stop()
+}
+
+type batchListenerMock struct {
+	listenerMock
+	batches [][]ILObject
+}
+
+func (l *batchListenerMock) LogImpressions(data []ILObject) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.batches = append(l.batches, data)
+}
+
+func (l *batchListenerMock) batchSizes() []int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	sizes := make([]int, 0, len(l.batches))
+	for _, batch := range l.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestBatchListenerBySize(t *testing.T) {
+	listener := &batchListenerMock{}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{BatchSize: 2, FlushInterval: 60}, logging.NewLogger(nil))
+
+	wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+	sizes := listener.batchSizes()
+	if len(sizes) != 1 || sizes[0] != 2 {
+		t.Error("One full batch should be delivered. Actual:", sizes)
+	}
+	if len(listener.features()) != 0 {
+		t.Error("Single impression method should not be called for batch listeners")
+	}
+
+	wrapper.Stop()
+	sizes = listener.batchSizes()
+	if len(sizes) != 2 || sizes[1] != 1 {
+		t.Error("Remaining impressions should be flushed when stopping. Actual:", sizes)
+	}
+}
+
+func TestBatchListenerByInterval(t *testing.T) {
+	listener := &batchListenerMock{}
+	wrapper := NewImpressionListenerWrapperWithOptions(listener, metadata, Options{
+		QueueSize:     10,
+		Workers:       2,
+		BatchSize:     100,
+		FlushInterval: 1,
+	}, logging.NewLogger(nil))
+
+	wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+	time.Sleep(1500 * time.Millisecond)
+
+	sizes := listener.batchSizes()
+	if len(sizes) != 1 || sizes[0] != 3 {
+		t.Error("Buffered impressions should be flushed after the interval. Actual:", sizes)
+	}
+
+	wrapper.Stop()
+	if len(listener.batchSizes()) != 1 {
+		t.Error("Empty buffers should not be flushed")
+	}
+}
+
+func TestLegacyWrapperWithBatchListener(t *testing.T) {
+	listener := &batchListenerMock{}
+	wrapper := NewImpressionListenerWrapper(listener, metadata)
+	if wrapper.stopFlusher != nil {
+		t.Error("The legacy constructor should not start the batch flusher")
+	}
+
+	wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+	if len(listener.batchSizes()) != 0 {
+		t.Error("Impressions should be buffered until the batch is full")
+	}
+
+	wrapper.Flush()
+	sizes := listener.batchSizes()
+	if len(sizes) != 1 || sizes[0] != 3 {
+		t.Error("Buffered impressions should be delivered on Flush. Actual:", sizes)
+	}
+}
+
+func TestMultipleListenersWithFilters(t *testing.T) {
+	analytics := &listenerMock{}
+	audit := &batchListenerMock{}
+	debug := &listenerMock{}
+	wrapper := NewMultiImpressionListenerWrapper([]FilteredListener{
+		{Listener: &panickingListener{}},
+		{Listener: analytics},
+		{Listener: audit, Filter: Filter{Features: []string{"f1", "f3"}}},
+		{Listener: debug, Filter: Filter{Treatments: []string{"off"}}},
+	}, metadata, Options{BatchSize: 10}, logging.NewLogger(nil))
+
+	wrapper.SendDataToClient(impressionsFor("f1", "f2", "f3"), nil)
+	wrapper.Stop()
+
+	if len(analytics.features()) != 3 {
+		t.Error("A panicking listener should not affect the others")
+	}
+	sizes := audit.batchSizes()
+	if len(sizes) != 1 || sizes[0] != 2 {
+		t.Error("Only matching impressions should be batched. Actual:", sizes)
+	}
+	if len(debug.features()) != 0 {
+		t.Error("Filtered out impressions should not be delivered")
+	}
+}
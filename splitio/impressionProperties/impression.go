@@ -0,0 +1,41 @@
+// Package impressionproperties contains the storages and the recorder of the impressions generated by the SDK. They
+// carry the properties attached to each evaluation, which the impression formats of go-split-commons can't hold, so
+// they take the place of its impression storages and recorder
+package impressionproperties
+
+import (
+	"encoding/json"
+
+	"github.com/splitio/go-split-commons/dtos"
+)
+
+// Impression struct mapping an impression along with the properties attached to its evaluation, serialized as a
+// JSON string. Impressions of evaluations without properties have an empty Properties field
+type Impression struct {
+	dtos.Impression
+	Properties string `json:"properties,omitempty"`
+}
+
+// Producer is implemented by the impression storages able to keep the properties attached to impressions
+type Producer interface {
+	LogImpressionsWithProperties(impressions []Impression) error
+}
+
+// Attach returns the impressions along with the serialized properties. If properties is empty, impressions are
+// returned without properties
+func Attach(impressions []dtos.Impression, properties map[string]interface{}) ([]Impression, error) {
+	serialized := ""
+	if len(properties) > 0 {
+		encoded, err := json.Marshal(properties)
+		if err != nil {
+			return nil, err
+		}
+		serialized = string(encoded)
+	}
+
+	result := make([]Impression, 0, len(impressions))
+	for _, impression := range impressions {
+		result = append(result, Impression{Impression: impression, Properties: serialized})
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package impressionproperties
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// timeFrame is the period, in milliseconds, within which repeated impressions are deduped in optimized mode
+const timeFrame = int64(3600 * 1000)
+
+type observed struct {
+	hash uint64
+	time int64
+}
+
+// Observer dedupes impressions taking their properties into account, so that an impression is only deduped against
+// the ones carrying the same properties and properties are never lost. It remembers up to size impressions,
+// forgetting the least recently seen ones
+type Observer struct {
+	size    int
+	entries map[uint64]*list.Element
+	order   *list.List
+	mutex   sync.Mutex
+}
+
+// NewObserver instantiates a new Observer remembering up to size impressions
+func NewObserver(size int) *Observer {
+	return &Observer{
+		size:    size,
+		entries: make(map[uint64]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// TestAndSet returns the time of the last impression seen with the same key, feature, treatment, label, change
+// number and properties, or 0 if there's none, and records the time of impression
+func (o *Observer) TestAndSet(impression Impression) int64 {
+	hash := hashOf(impression)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if element, ok := o.entries[hash]; ok {
+		entry := element.Value.(*observed)
+		previous := entry.time
+		entry.time = impression.Time
+		o.order.MoveToFront(element)
+		return previous
+	}
+
+	o.entries[hash] = o.order.PushFront(&observed{hash: hash, time: impression.Time})
+	if o.order.Len() > o.size {
+		oldest := o.order.Back()
+		o.order.Remove(oldest)
+		delete(o.entries, oldest.Value.(*observed).hash)
+	}
+	return 0
+}
+
+// Dedupe returns the impressions to be logged in optimized mode, setting their previous time. An impression is
+// discarded if an identical one, properties included, was seen within the same hour
+func (o *Observer) Dedupe(impressions []Impression) []Impression {
+	forLog := make([]Impression, 0, len(impressions))
+	for _, impression := range impressions {
+		impression.Pt = o.TestAndSet(impression)
+		if impression.Pt == 0 || impression.Pt < impression.Time-impression.Time%timeFrame {
+			forLog = append(forLog, impression)
+		}
+	}
+	return forLog
+}
+
+func hashOf(impression Impression) uint64 {
+	hasher := fnv.New64a()
+	fmt.Fprintf(
+		hasher,
+		"%s:%s:%s:%s:%d:%s",
+		impression.KeyName,
+		impression.FeatureName,
+		impression.Treatment,
+		impression.Label,
+		impression.ChangeNumber,
+		impression.Properties,
+	)
+	return hasher.Sum64()
+}
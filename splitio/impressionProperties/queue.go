@@ -0,0 +1,93 @@
+package impressionproperties
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// queueFullSignal is sent to the synchronizer once the queue is full, as the storages of go-split-commons do, so
+// that the queued impressions are submitted ahead of schedule
+const queueFullSignal = "IMPRESSIONS_FULL"
+
+// ErrQueueFull is returned when impressions are discarded because the queue is full
+var ErrQueueFull = errors.New("impressions queue is full")
+
+// Queue keeps the impressions in memory, along with their properties, until they're submitted by a Recorder. It
+// implements both storage.ImpressionStorageProducer and Producer
+type Queue struct {
+	impressions []Impression
+	maxSize     int
+	fullChan    chan<- string
+	logger      logging.LoggerInterface
+	mutex       sync.Mutex
+}
+
+// NewQueue instantiates a new Queue holding up to maxSize impressions, or all of them if maxSize is not positive.
+// Once full, the queue signals fullChan, if set, and discards the incoming impressions
+func NewQueue(maxSize int, fullChan chan<- string, logger logging.LoggerInterface) *Queue {
+	return &Queue{
+		impressions: make([]Impression, 0),
+		maxSize:     maxSize,
+		fullChan:    fullChan,
+		logger:      logger,
+	}
+}
+
+// LogImpressions queues impressions without properties
+func (q *Queue) LogImpressions(impressions []dtos.Impression) error {
+	toQueue, _ := Attach(impressions, nil)
+	return q.LogImpressionsWithProperties(toQueue)
+}
+
+// LogImpressionsWithProperties queues the impressions along with their properties
+func (q *Queue) LogImpressionsWithProperties(impressions []Impression) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for _, impression := range impressions {
+		if q.maxSize > 0 && len(q.impressions) >= q.maxSize {
+			q.signalFull()
+			return ErrQueueFull
+		}
+		q.impressions = append(q.impressions, impression)
+	}
+	return nil
+}
+
+// signalFull notifies that the queue is full without blocking, as a single pending signal is enough
+func (q *Queue) signalFull() {
+	if q.fullChan == nil {
+		return
+	}
+	select {
+	case q.fullChan <- queueFullSignal:
+	default:
+		q.logger.Debug("Impressions queue is full and a signal is already pending")
+	}
+}
+
+// PopN removes and returns up to n impressions, oldest first
+func (q *Queue) PopN(n int64) []Impression {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if int64(len(q.impressions)) < n {
+		n = int64(len(q.impressions))
+	}
+	popped := q.impressions[:n]
+	q.impressions = q.impressions[n:]
+	return popped
+}
+
+// Count returns how many impressions are queued
+func (q *Queue) Count() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.impressions)
+}
+
+// Empty returns true if no impression is queued
+func (q *Queue) Empty() bool {
+	return q.Count() == 0
+}
@@ -0,0 +1,109 @@
+package impressionproperties
+
+import (
+	"encoding/json"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const impressionsPath = "/testImpressions/bulk"
+
+// ImpressionDTO struct mapping a single impression in the payload sent to split servers. It matches the format of
+// go-split-commons plus a "properties" field
+type ImpressionDTO struct {
+	KeyName      string `json:"keyName"`
+	Treatment    string `json:"treatment"`
+	Time         int64  `json:"time"`
+	ChangeNumber int64  `json:"changeNumber"`
+	Label        string `json:"label"`
+	BucketingKey string `json:"bucketingKey,omitempty"`
+	Pt           int64  `json:"pt,omitempty"`
+	Properties   string `json:"properties,omitempty"`
+}
+
+// ImpressionsDTO struct mapping the impressions of a feature in the payload sent to split servers
+type ImpressionsDTO struct {
+	TestName       string          `json:"testName"`
+	KeyImpressions []ImpressionDTO `json:"keyImpressions"`
+}
+
+// Poster is the subset of the HTTP client used to submit impressions
+type Poster interface {
+	Post(service string, body []byte, headers map[string]string) error
+}
+
+// Recorder submits the queued impressions, along with their properties, to split servers. It's the impression
+// recorder worker run by the synchronizer of go-split-commons, which also calls it when the queue is full
+type Recorder struct {
+	queue           *Queue
+	client          Poster
+	impressionsMode string
+	logger          logging.LoggerInterface
+}
+
+// NewRecorder instantiates a new Recorder
+func NewRecorder(queue *Queue, client Poster, impressionsMode string, logger logging.LoggerInterface) *Recorder {
+	return &Recorder{
+		queue:           queue,
+		client:          client,
+		impressionsMode: impressionsMode,
+		logger:          logger,
+	}
+}
+
+// SynchronizeImpressions pops up to bulkSize impressions and posts them
+func (r *Recorder) SynchronizeImpressions(bulkSize int64) error {
+	impressions := r.queue.PopN(bulkSize)
+	if len(impressions) == 0 {
+		return nil
+	}
+
+	byFeature := make(map[string][]ImpressionDTO)
+	features := make([]string, 0)
+	for _, impression := range impressions {
+		if _, ok := byFeature[impression.FeatureName]; !ok {
+			features = append(features, impression.FeatureName)
+		}
+		byFeature[impression.FeatureName] = append(byFeature[impression.FeatureName], ImpressionDTO{
+			KeyName:      impression.KeyName,
+			Treatment:    impression.Treatment,
+			Time:         impression.Time,
+			ChangeNumber: impression.ChangeNumber,
+			Label:        impression.Label,
+			BucketingKey: impression.BucketingKey,
+			Pt:           impression.Pt,
+			Properties:   impression.Properties,
+		})
+	}
+
+	bulk := make([]ImpressionsDTO, 0, len(features))
+	for _, feature := range features {
+		bulk = append(bulk, ImpressionsDTO{TestName: feature, KeyImpressions: byFeature[feature]})
+	}
+
+	data, err := json.Marshal(bulk)
+	if err != nil {
+		r.logger.Error("Error marshalling impressions", err.Error())
+		return err
+	}
+
+	err = r.client.Post(impressionsPath, data, map[string]string{"SplitSDKImpressionsMode": r.impressionsMode})
+	if err != nil {
+		r.logger.Error("Error posting impressions", err.Error())
+		return err
+	}
+	return nil
+}
+
+// FlushImpressions posts every queued impression, in bulks of bulkSize
+func (r *Recorder) FlushImpressions(bulkSize int64) error {
+	if bulkSize <= 0 {
+		bulkSize = int64(r.queue.Count())
+	}
+	for r.queue.Count() > 0 {
+		if err := r.SynchronizeImpressions(bulkSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package impressionproperties
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+type posterMock struct {
+	PostCall func(service string, body []byte, headers map[string]string) error
+}
+
+func (p *posterMock) Post(service string, body []byte, headers map[string]string) error {
+	return p.PostCall(service, body, headers)
+}
+
+func TestQueue(t *testing.T) {
+	fullChan := make(chan string, 1)
+	queue := NewQueue(3, fullChan, logging.NewLogger(nil))
+
+	queue.LogImpressions([]dtos.Impression{{FeatureName: "feature1"}})
+	impressions, _ := Attach([]dtos.Impression{{FeatureName: "feature2"}, {FeatureName: "feature3"}}, map[string]interface{}{"page": "home"})
+	err := queue.LogImpressionsWithProperties(impressions)
+	if err != nil || queue.Count() != 3 {
+		t.Error("Impressions should be queued with and without properties")
+	}
+
+	err = queue.LogImpressions([]dtos.Impression{{FeatureName: "feature4"}})
+	if err != ErrQueueFull || queue.Count() != 3 {
+		t.Error("Impressions exceeding the queue size should be discarded")
+	}
+	select {
+	case signal := <-fullChan:
+		if signal != "IMPRESSIONS_FULL" {
+			t.Error("Unexpected signal", signal)
+		}
+	default:
+		t.Error("The synchronizer should be signaled once the queue is full")
+	}
+
+	popped := queue.PopN(2)
+	if len(popped) != 2 || popped[0].FeatureName != "feature1" || popped[0].Properties != "" || popped[1].Properties != `{"page":"home"}` {
+		t.Error("Oldest impressions should be popped with their properties", popped)
+	}
+	if queue.Count() != 1 || queue.Empty() {
+		t.Error("Popped impressions should be removed")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	queue := NewQueue(0, nil, logging.NewLogger(nil))
+	impressions, _ := Attach([]dtos.Impression{
+		{FeatureName: "feature1", KeyName: "key1", Treatment: "on", Time: 1, Pt: 0},
+		{FeatureName: "feature2", KeyName: "key1", Treatment: "off", Time: 2},
+		{FeatureName: "feature1", KeyName: "key2", Treatment: "on", Time: 3, Pt: 1},
+	}, map[string]interface{}{"requestId": "abc"})
+	queue.LogImpressionsWithProperties(impressions)
+
+	var posted [][]ImpressionsDTO
+	poster := &posterMock{
+		PostCall: func(service string, body []byte, headers map[string]string) error {
+			if service != "/testImpressions/bulk" {
+				t.Error("Wrong service", service)
+			}
+			if headers["SplitSDKImpressionsMode"] != "optimized" {
+				t.Error("Impressions mode should be sent")
+			}
+			var bulk []ImpressionsDTO
+			if err := json.Unmarshal(body, &bulk); err != nil {
+				t.Error(err)
+			}
+			posted = append(posted, bulk)
+			return nil
+		},
+	}
+
+	recorder := NewRecorder(queue, poster, "optimized", logging.NewLogger(nil))
+	if err := recorder.FlushImpressions(2); err != nil {
+		t.Error("It should not return err")
+	}
+	if len(posted) != 2 || queue.Count() != 0 {
+		t.Error("Every impression should be posted in bulks", posted)
+	}
+	first := posted[0]
+	if len(first) != 2 || first[0].TestName != "feature1" || first[1].TestName != "feature2" {
+		t.Error("Impressions should be grouped by feature", first)
+	}
+	if first[0].KeyImpressions[0].Properties != `{"requestId":"abc"}` {
+		t.Error("Properties should be posted")
+	}
+	if posted[1][0].KeyImpressions[0].Pt != 1 {
+		t.Error("Previous time should be posted")
+	}
+
+	queue.LogImpressions([]dtos.Impression{{FeatureName: "feature1"}})
+	poster.PostCall = func(service string, body []byte, headers map[string]string) error {
+		return errors.New("some")
+	}
+	if err := recorder.SynchronizeImpressions(10); err == nil {
+		t.Error("It should return err")
+	}
+}
+
+func TestObserver(t *testing.T) {
+	observer := NewObserver(2)
+	plain, _ := Attach([]dtos.Impression{
+		{FeatureName: "feature1", KeyName: "key1", Treatment: "on", Time: 1000},
+		{FeatureName: "feature1", KeyName: "key1", Treatment: "on", Time: 2000},
+	}, nil)
+	withProperties, _ := Attach([]dtos.Impression{
+		{FeatureName: "feature1", KeyName: "key1", Treatment: "on", Time: 3000},
+	}, map[string]interface{}{"page": "home"})
+
+	forLog := observer.Dedupe(append(plain, withProperties...))
+	if len(forLog) != 2 || forLog[0].Pt != 0 || forLog[1].Properties != `{"page":"home"}` || forLog[1].Pt != 0 {
+		t.Error("Impressions should only be deduped against the ones with the same properties", forLog)
+	}
+
+	observer.TestAndSet(Impression{Impression: dtos.Impression{FeatureName: "feature2"}})
+	if observer.TestAndSet(plain[0]) != 0 {
+		t.Error("Least recently seen impressions should be forgotten")
+	}
+}
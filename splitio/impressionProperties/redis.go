@@ -0,0 +1,78 @@
+package impressionproperties
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+	predis "github.com/splitio/go-toolkit/redis"
+)
+
+const (
+	// redisImpressionsKey is the list the synchronizer reads the impressions from. It's the key written by the
+	// impression storage of go-split-commons, which doesn't export it
+	redisImpressionsKey = "SPLITIO.impressions"
+	redisImpressionsTTL = time.Hour
+)
+
+// redisImpression struct mapping an impression in the redis impressions list
+type redisImpression struct {
+	Metadata   dtos.Metadata `json:"m"`
+	Impression Impression    `json:"i"`
+}
+
+// RedisStorage writes the impressions to the redis impressions list, in the format used by go-split-commons plus a
+// "properties" field. It implements both storage.ImpressionStorageProducer and Producer
+type RedisStorage struct {
+	client   *predis.PrefixedRedisClient
+	metadata dtos.Metadata
+	logger   logging.LoggerInterface
+	mutex    sync.Mutex
+}
+
+// NewRedisStorage instantiates a new RedisStorage
+func NewRedisStorage(client *predis.PrefixedRedisClient, metadata dtos.Metadata, logger logging.LoggerInterface) *RedisStorage {
+	return &RedisStorage{
+		client:   client,
+		metadata: metadata,
+		logger:   logger,
+	}
+}
+
+// LogImpressions pushes impressions without properties to redis
+func (r *RedisStorage) LogImpressions(impressions []dtos.Impression) error {
+	toStore, _ := Attach(impressions, nil)
+	return r.LogImpressionsWithProperties(toStore)
+}
+
+// LogImpressionsWithProperties pushes the impressions along with their properties to redis
+func (r *RedisStorage) LogImpressionsWithProperties(impressions []Impression) error {
+	values := make([]interface{}, 0, len(impressions))
+	for _, impression := range impressions {
+		value, err := json.Marshal(redisImpression{Metadata: r.metadata, Impression: impression})
+		if err != nil {
+			r.logger.Error("Error encoding impression in json", err.Error())
+			continue
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	inserted, err := r.client.RPush(redisImpressionsKey, values...)
+	if err != nil {
+		r.logger.Error("Error pushing impressions to redis", err.Error())
+		return err
+	}
+
+	// Set the expiration only when the list has just been created, as go-split-commons does
+	if inserted == int64(len(values)) {
+		r.client.Expire(redisImpressionsKey, redisImpressionsTTL)
+	}
+	return nil
+}
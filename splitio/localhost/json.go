@@ -0,0 +1,89 @@
+package localhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/splitio/go-client/splitio/snapshot"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/datastructures/set"
+)
+
+// isSnapshot returns true if the JSON content is a snapshot exported by SplitManager rather than a splitChanges
+// response
+func isSnapshot(data []byte) bool {
+	var root map[string]json.RawMessage
+	if json.Unmarshal(data, &root) != nil {
+		return false
+	}
+	_, ok := root["version"]
+	return ok
+}
+
+// parseSplitChanges reads splits in the format served by the splitChanges endpoint
+func parseSplitChanges(data []byte) (*dtos.SplitChangesDTO, error) {
+	var splitChanges dtos.SplitChangesDTO
+	err := json.Unmarshal(data, &splitChanges)
+	if err != nil {
+		return nil, err
+	}
+	if splitChanges.Splits == nil {
+		return nil, fmt.Errorf("splits must be set")
+	}
+	return &splitChanges, nil
+}
+
+// readSegmentDirectory reads every JSON file of the directory in the format served by the segmentChanges endpoint.
+// The keys of each segment are the ones added minus the ones removed. Segments without a name are named after
+// their file
+func readSegmentDirectory(files fileSystem, directory string) ([]snapshot.SegmentContent, error) {
+	paths, err := files.glob(files.join(directory, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	segments := make([]snapshot.SegmentContent, 0, len(paths))
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := files.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var segmentChanges dtos.SegmentChangesDTO
+		err = json.Unmarshal(data, &segmentChanges)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+		if segmentChanges.Name == "" {
+			segmentChanges.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		if previous, ok := names[segmentChanges.Name]; ok {
+			return nil, fmt.Errorf("%s: segment %s is already defined in %s", path, segmentChanges.Name, previous)
+		}
+		names[segmentChanges.Name] = path
+
+		keys := set.NewSet()
+		for _, key := range segmentChanges.Added {
+			keys.Add(key)
+		}
+		for _, key := range segmentChanges.Removed {
+			keys.Remove(key)
+		}
+		segment := snapshot.SegmentContent{
+			Name:         segmentChanges.Name,
+			ChangeNumber: segmentChanges.Till,
+			Keys:         make([]string, 0, keys.Size()),
+		}
+		for _, key := range keys.List() {
+			segment.Keys = append(segment.Keys, key.(string))
+		}
+		sort.Strings(segment.Keys)
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
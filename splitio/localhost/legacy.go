@@ -0,0 +1,171 @@
+package localhost
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// legacyYAMLEntry is the definition of a treatment in the legacy YAML format, a list of single key mappings from
+// split name to entry. Keys can be either a string or a list of strings
+type legacyYAMLEntry struct {
+	Treatment string      `yaml:"treatment"`
+	Keys      interface{} `yaml:"keys"`
+	Config    string      `yaml:"config"`
+}
+
+// isLegacyYAML returns true if data is a YAML list, as used by the legacy YAML format
+func isLegacyYAML(data []byte) bool {
+	var root []map[string]legacyYAMLEntry
+	return yaml.Unmarshal(data, &root) == nil && len(root) > 0
+}
+
+// parseLegacyYAML reads the legacy YAML format, where entries with keys whitelist them and entries without keys
+// set the treatment for every other key
+func parseLegacyYAML(data []byte, logger logging.LoggerInterface) ([]dtos.SplitDTO, error) {
+	var root []map[string]legacyYAMLEntry
+	err := yaml.Unmarshal(data, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := legacyEntryLines(data, len(root))
+	whitelists := make(map[string][]yamlCondition)
+	rollouts := make(map[string]yamlCondition)
+	configurations := make(map[string]map[string]string)
+	names := make([]string, 0)
+	for index, item := range root {
+		for name, entry := range item {
+			if entry.Treatment == "" {
+				return nil, fmt.Errorf("%s: split %s: treatment must be set", lines[index], name)
+			}
+			keys, err := legacyKeys(entry.Keys)
+			if err != nil {
+				return nil, fmt.Errorf("%s: split %s: %s", lines[index], name, err.Error())
+			}
+
+			if _, ok := configurations[name]; !ok {
+				configurations[name] = make(map[string]string)
+				names = append(names, name)
+			}
+			if entry.Config != "" {
+				configurations[name][entry.Treatment] = entry.Config
+			}
+			if len(keys) > 0 {
+				whitelists[name] = append(whitelists[name], yamlCondition{Keys: keys, Treatment: entry.Treatment})
+			} else {
+				rollouts[name] = yamlCondition{Treatment: entry.Treatment}
+			}
+		}
+	}
+
+	sort.Strings(names)
+	splits := make([]dtos.SplitDTO, 0, len(names))
+	for _, name := range names {
+		split := yamlSplit{Configurations: configurations[name], Conditions: whitelists[name]}
+		if rollout, ok := rollouts[name]; ok {
+			split.Conditions = append(split.Conditions, rollout)
+		}
+		splitDTO, err := split.toDTO(name, logger)
+		if err != nil {
+			return nil, fmt.Errorf("split %s: %s", name, err.Error())
+		}
+		splits = append(splits, *splitDTO)
+	}
+	return splits, nil
+}
+
+// legacyEntryLines returns where each of the count entries of the root list starts, as "line N". Entries are found
+// as the lines starting with '-' at the indentation of the first one. If they can't be told apart, such as in
+// flow style lists, entries are named by position instead, as "entry N"
+func legacyEntryLines(data []byte, count int) []string {
+	found := make([]string, 0, count)
+	indentation := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimLeft(text, " ")
+		if !strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "---") {
+			continue
+		}
+		if indentation == -1 {
+			indentation = len(text) - len(trimmed)
+		}
+		if len(text)-len(trimmed) == indentation {
+			found = append(found, fmt.Sprintf("line %d", line))
+		}
+	}
+	if len(found) == count {
+		return found
+	}
+
+	byPosition := make([]string, 0, count)
+	for index := 0; index < count; index++ {
+		byPosition = append(byPosition, fmt.Sprintf("entry %d", index+1))
+	}
+	return byPosition
+}
+
+func legacyKeys(keys interface{}) ([]string, error) {
+	switch typed := keys.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{typed}, nil
+	case []interface{}:
+		list := make([]string, 0, len(typed))
+		for _, key := range typed {
+			asString, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys must be strings. Actual is: %v", key)
+			}
+			list = append(list, asString)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("keys must be a string or a list of strings. Actual is: %v", keys)
+	}
+}
+
+// parseLegacyLines reads the legacy format with a split name and its treatment on each line. Empty lines and lines
+// starting with '#' are skipped
+func parseLegacyLines(data []byte, logger logging.LoggerInterface) ([]dtos.SplitDTO, error) {
+	treatments := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected a split name and a treatment. Actual is: %s", line, text)
+		}
+		treatments[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(treatments))
+	for name := range treatments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	splits := make([]dtos.SplitDTO, 0, len(names))
+	for _, name := range names {
+		split := yamlSplit{Conditions: []yamlCondition{{Treatment: treatments[name]}}}
+		splitDTO, err := split.toDTO(name, logger)
+		if err != nil {
+			return nil, fmt.Errorf("split %s: %s", name, err.Error())
+		}
+		splits = append(splits, *splitDTO)
+	}
+	return splits, nil
+}
@@ -0,0 +1,65 @@
+package localhost
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestParseLegacyYAML(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testdata/splits.yaml")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	splits, err := parseLegacyYAML(data, logging.NewLogger(nil))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(splits) != 4 || splits[0].Name != "my_feature" || splits[3].Name != "other_feature_3" {
+		t.Error("Splits should be sorted by name. Actual:", splits)
+		return
+	}
+
+	myFeature := splits[0]
+	if len(myFeature.Conditions) != 2 || myFeature.Conditions[0].ConditionType != "WHITELIST" || myFeature.DefaultTreatment != "control" {
+		t.Error("Entries with keys should be whitelists")
+	}
+	if len(myFeature.Configurations) != 2 {
+		t.Error("Configs should be set per treatment")
+	}
+
+	otherFeature3 := splits[3]
+	if len(otherFeature3.Conditions) != 2 || otherFeature3.Conditions[0].ConditionType != "WHITELIST" || otherFeature3.Conditions[1].ConditionType != "ROLLOUT" {
+		t.Error("Whitelists should be evaluated before the rollout condition")
+	}
+
+	_, err = parseLegacyYAML([]byte("# flags\n- s1:\n    treatment: \"on\"\n\n- s1:\n    keys: \"key\"\n"), logging.NewLogger(nil))
+	if err == nil || err.Error() != "line 5: split s1: treatment must be set" {
+		t.Error("Entries without treatment should fail naming their line. Actual:", err)
+	}
+
+	_, err = parseLegacyYAML([]byte("[{s1: {treatment: \"on\"}}, {s2: {keys: 1}}]"), logging.NewLogger(nil))
+	if err == nil || err.Error() != "entry 2: split s2: treatment must be set" {
+		t.Error("Entries of flow style lists should be named by position. Actual:", err)
+	}
+}
+
+func TestParseLegacyLines(t *testing.T) {
+	splits, err := parseLegacyLines([]byte("# comment\nfeature_b off\n\nfeature_a on\n"), logging.NewLogger(nil))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(splits) != 2 || splits[0].Name != "feature_a" || splits[0].Conditions[0].Partitions[0].Treatment != "on" {
+		t.Error("Splits should be read from every line. Actual:", splits)
+	}
+
+	_, err = parseLegacyLines([]byte("feature_a on\nfeature_b\n"), logging.NewLogger(nil))
+	if err == nil || err.Error() != "line 2: expected a split name and a treatment. Actual is: feature_b" {
+		t.Error("Error should name the line. Actual:", err)
+	}
+}
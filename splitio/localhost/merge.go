@@ -0,0 +1,112 @@
+package localhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/splitio/go-client/splitio/snapshot"
+	"github.com/splitio/go-split-commons/dtos"
+)
+
+// mergeDefinitions merges the definitions read from several files. Definitions of later files take precedence:
+// a split defined in several files keeps the attributes of the last one, its conditions come first and replace
+// the earlier conditions with the same matchers, and configurations are merged per treatment. Segments defined in
+// several files keep the keys of the last one
+func mergeDefinitions(files []*definitions) *definitions {
+	if len(files) == 1 {
+		return files[0]
+	}
+
+	merged := &definitions{till: -1}
+	splits := make(map[string]dtos.SplitDTO)
+	for _, file := range files {
+		for _, split := range file.splits {
+			if earlier, ok := splits[split.Name]; ok {
+				split = mergeSplit(earlier, split)
+			}
+			splits[split.Name] = split
+		}
+		merged.segments = mergeSegments(merged.segments, file.segments)
+	}
+
+	merged.splits = make([]dtos.SplitDTO, 0, len(splits))
+	for _, split := range splits {
+		merged.splits = append(merged.splits, split)
+	}
+	sort.Slice(merged.splits, func(i, j int) bool { return merged.splits[i].Name < merged.splits[j].Name })
+	return merged
+}
+
+func mergeSplit(earlier dtos.SplitDTO, later dtos.SplitDTO) dtos.SplitDTO {
+	merged := later
+
+	merged.Configurations = make(map[string]string, len(earlier.Configurations)+len(later.Configurations))
+	for treatment, config := range earlier.Configurations {
+		merged.Configurations[treatment] = config
+	}
+	for treatment, config := range later.Configurations {
+		merged.Configurations[treatment] = config
+	}
+
+	merged.Conditions = make([]dtos.ConditionDTO, 0, len(earlier.Conditions)+len(later.Conditions))
+	overridden := make(map[string]struct{}, len(later.Conditions))
+	for _, condition := range later.Conditions {
+		overridden[conditionID(condition)] = struct{}{}
+		merged.Conditions = append(merged.Conditions, condition)
+	}
+	for _, condition := range earlier.Conditions {
+		if _, ok := overridden[conditionID(condition)]; !ok {
+			merged.Conditions = append(merged.Conditions, condition)
+		}
+	}
+	return merged
+}
+
+// conditionID identifies a condition by the keys it applies to
+func conditionID(condition dtos.ConditionDTO) string {
+	encoded, _ := json.Marshal(condition.MatcherGroup)
+	return string(encoded)
+}
+
+// mergeSegments adds the later segments to the earlier ones, replacing the ones with the same name
+func mergeSegments(earlier []snapshot.SegmentContent, later []snapshot.SegmentContent) []snapshot.SegmentContent {
+	merged := make([]snapshot.SegmentContent, 0, len(earlier)+len(later))
+	replaced := make(map[string]struct{}, len(later))
+	for _, segment := range later {
+		replaced[segment.Name] = struct{}{}
+	}
+	for _, segment := range earlier {
+		if _, ok := replaced[segment.Name]; !ok {
+			merged = append(merged, segment)
+		}
+	}
+	return append(merged, later...)
+}
+
+// fileError prefixes err with the path of the file it was found in and, for JSON syntax and type errors, including
+// the ones found reading snapshots, with the line of the error. Errors of YAML and legacy files already name their
+// line
+func fileError(path string, data []byte, err error) error {
+	cause := err
+	if parseError, ok := err.(*snapshot.ParseError); ok {
+		cause = parseError.Err
+	}
+
+	var offset int64 = -1
+	switch typed := cause.(type) {
+	case *json.SyntaxError:
+		offset = typed.Offset
+	case *json.UnmarshalTypeError:
+		offset = typed.Offset
+	}
+	if offset >= 0 && offset <= int64(len(data)) {
+		line := bytes.Count(data[:offset], []byte("\n")) + 1
+		err = fmt.Errorf("line %d: %s", line, err.Error())
+	}
+	if path == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %s", path, err.Error())
+}
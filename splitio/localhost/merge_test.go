@@ -0,0 +1,87 @@
+package localhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/splitio/go-client/splitio/snapshot"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestMergeDefinitions(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	shared, err := parseYAMLWithRules([]byte(`splits:
+  feature:
+    defaultTreatment: "off"
+    configurations:
+      "on": "shared on"
+      "off": "shared off"
+    conditions:
+      - keys: ["qa_user"]
+        treatment: "on"
+      - treatment: "off"
+  shared_only:
+    conditions:
+      - treatment: "on"
+`), logger)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	local, err := parseLegacyYAML([]byte("- feature:\n    treatment: \"v2\"\n    keys: \"developer\"\n    config: \"local v2\"\n- feature:\n    treatment: \"on\"\n    config: \"local on\"\n"), logger)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	merged := mergeDefinitions([]*definitions{
+		{splits: shared, segments: []snapshot.SegmentContent{{Name: "s1", Keys: []string{"a"}}, {Name: "s2", Keys: []string{"b"}}}},
+		{splits: local, segments: []snapshot.SegmentContent{{Name: "s1", Keys: []string{"c"}}}},
+	})
+
+	if len(merged.splits) != 2 || merged.splits[0].Name != "feature" || merged.splits[1].Name != "shared_only" {
+		t.Error("Splits of every file should be kept. Actual:", merged.splits)
+		return
+	}
+	feature := merged.splits[0]
+	if len(feature.Conditions) != 3 {
+		t.Error("The rollout condition should be replaced and the whitelists kept. Actual:", feature.Conditions)
+		return
+	}
+	if feature.Conditions[0].Partitions[0].Treatment != "v2" || feature.Conditions[1].Partitions[0].Treatment != "on" || feature.Conditions[2].ConditionType != "WHITELIST" {
+		t.Error("Conditions of later files should come first")
+	}
+	if feature.Configurations["on"] != "local on" || feature.Configurations["off"] != "shared off" || feature.Configurations["v2"] != "local v2" {
+		t.Error("Configurations should be merged per treatment. Actual:", feature.Configurations)
+	}
+	if merged.versioned || merged.till != -1 {
+		t.Error("Merged definitions should not be versioned")
+	}
+	if len(merged.segments) != 2 || merged.segments[0].Name != "s2" || merged.segments[1].Keys[0] != "c" {
+		t.Error("Segments of later files should replace the earlier ones. Actual:", merged.segments)
+	}
+}
+
+func TestFileError(t *testing.T) {
+	data := []byte("{\n  \"splits\": [\n    {\"name\": 1}\n  ]\n}")
+	var splitChanges dtos.SplitChangesDTO
+	err := fileError("flags.json", data, json.Unmarshal(data, &splitChanges))
+	if err == nil || err.Error()[:len("flags.json: line 3: ")] != "flags.json: line 3: " {
+		t.Error("JSON type errors should name the file and line. Actual:", err)
+	}
+
+	data = []byte("{\n  \"splits\": [\n  ,\n}")
+	err = fileError("flags.json", data, json.Unmarshal(data, &splitChanges))
+	if err == nil || err.Error()[:len("flags.json: line 3: ")] != "flags.json: line 3: " {
+		t.Error("JSON syntax errors should name the file and line. Actual:", err)
+	}
+
+	data = []byte("{\n  \"version\": 1,\n  \"splits\": {}\n}")
+	_, err = snapshot.Read(bytes.NewReader(data))
+	err = fileError("snapshot.json", data, err)
+	if err == nil || err.Error()[:len("snapshot.json: line 3: ")] != "snapshot.json: line 3: " {
+		t.Error("Snapshot type errors should name the file and line. Actual:", err)
+	}
+}
@@ -0,0 +1,140 @@
+package localhost
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	filesink "github.com/splitio/go-client/splitio/fileSink"
+	impressionproperties "github.com/splitio/go-client/splitio/impressionProperties"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// Recorder keeps the impressions and events generated in localhost mode so they can be inspected, and optionally
+// appends them to a file as JSON lines. It implements storage.ImpressionStorageProducer, impressionproperties.Producer
+// and storage.EventStorageProducer. Once a limit is reached, the oldest items are dropped
+type Recorder struct {
+	impressions    []impressionproperties.Impression
+	events         []dtos.EventDTO
+	maxImpressions int
+	maxEvents      int
+	file           *os.File
+	encoder        *json.Encoder
+	warned         bool
+	logger         logging.LoggerInterface
+	mutex          sync.Mutex
+}
+
+// NewRecorder instantiates a new Recorder keeping up to maxImpressions impressions and maxEvents events, or all of
+// them if the limit is not positive. If path is set, every impression and event is also appended to that file
+func NewRecorder(maxImpressions int, maxEvents int, path string, logger logging.LoggerInterface) (*Recorder, error) {
+	recorder := &Recorder{
+		impressions:    make([]impressionproperties.Impression, 0),
+		events:         make([]dtos.EventDTO, 0),
+		maxImpressions: maxImpressions,
+		maxEvents:      maxEvents,
+		logger:         logger,
+	}
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		recorder.file = file
+		recorder.encoder = json.NewEncoder(file)
+	}
+	return recorder, nil
+}
+
+// LogImpressions records impressions without properties
+func (r *Recorder) LogImpressions(impressions []dtos.Impression) error {
+	toRecord, _ := impressionproperties.Attach(impressions, nil)
+	return r.LogImpressionsWithProperties(toRecord)
+}
+
+// LogImpressionsWithProperties records the impressions along with their properties
+func (r *Recorder) LogImpressionsWithProperties(impressions []impressionproperties.Impression) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for index := range impressions {
+		r.impressions = append(r.impressions, impressions[index])
+		record := filesink.Record{Type: filesink.RecordTypeImpression, Impression: &impressions[index].Impression}
+		if impressions[index].Properties != "" {
+			json.Unmarshal([]byte(impressions[index].Properties), &record.Properties)
+		}
+		r.write(record)
+	}
+	if dropped := len(r.impressions) - r.maxImpressions; r.maxImpressions > 0 && dropped > 0 {
+		r.impressions = append(make([]impressionproperties.Impression, 0, r.maxImpressions), r.impressions[dropped:]...)
+		r.warnDropped()
+	}
+	return nil
+}
+
+// Push records the event
+func (r *Recorder) Push(event dtos.EventDTO, size int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+	r.write(filesink.Record{Type: filesink.RecordTypeEvent, Event: &event})
+	if dropped := len(r.events) - r.maxEvents; r.maxEvents > 0 && dropped > 0 {
+		r.events = append(make([]dtos.EventDTO, 0, r.maxEvents), r.events[dropped:]...)
+		r.warnDropped()
+	}
+	return nil
+}
+
+// RecordedImpressions returns the impressions recorded since the last reset, oldest first, along with their
+// properties
+func (r *Recorder) RecordedImpressions() []impressionproperties.Impression {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append(make([]impressionproperties.Impression, 0, len(r.impressions)), r.impressions...)
+}
+
+// RecordedEvents returns the events recorded since the last reset, oldest first
+func (r *Recorder) RecordedEvents() []dtos.EventDTO {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append(make([]dtos.EventDTO, 0, len(r.events)), r.events...)
+}
+
+// Reset discards the recorded impressions and events. The file, if any, is left untouched
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.impressions = make([]impressionproperties.Impression, 0)
+	r.events = make([]dtos.EventDTO, 0)
+	r.warned = false
+}
+
+// Close closes the file, if any. Impressions and events are still recorded in memory afterwards
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.encoder = nil
+	return err
+}
+
+func (r *Recorder) write(record filesink.Record) {
+	if r.encoder == nil {
+		return
+	}
+	err := r.encoder.Encode(record)
+	if err != nil {
+		r.logger.Error("Localhost: error writing recorded data:", err.Error())
+	}
+}
+
+func (r *Recorder) warnDropped() {
+	if !r.warned {
+		r.logger.Warning("Localhost: recording limit reached, dropping the oldest impressions and events. Call Reset to free them")
+		r.warned = true
+	}
+}
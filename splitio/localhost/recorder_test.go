@@ -0,0 +1,77 @@
+package localhost
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	filesink "github.com/splitio/go-client/splitio/fileSink"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_localhost")
+	if err != nil {
+		t.Error("Couldn't create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recorded.jsonl")
+
+	recorder, err := NewRecorder(2, 10, path, logging.NewLogger(nil))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	recorder.LogImpressions([]dtos.Impression{
+		{FeatureName: "feature1", KeyName: "user1", Treatment: "on"},
+		{FeatureName: "feature2", KeyName: "user1", Treatment: "off"},
+	})
+	recorder.LogImpressions([]dtos.Impression{{FeatureName: "feature3", KeyName: "user2", Treatment: "on"}})
+	recorder.Push(dtos.EventDTO{Key: "user1", EventTypeID: "checkout", TrafficTypeName: "user"}, 100)
+
+	impressions := recorder.RecordedImpressions()
+	if len(impressions) != 2 || impressions[0].FeatureName != "feature2" || impressions[1].FeatureName != "feature3" {
+		t.Error("The oldest impressions should be dropped once the limit is reached. Actual:", impressions)
+	}
+	events := recorder.RecordedEvents()
+	if len(events) != 1 || events[0].EventTypeID != "checkout" {
+		t.Error("Event should be recorded. Actual:", events)
+	}
+
+	recorder.Reset()
+	if len(recorder.RecordedImpressions()) != 0 || len(recorder.RecordedEvents()) != 0 {
+		t.Error("Recorded data should be discarded on reset")
+	}
+
+	err = recorder.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+	types := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record filesink.Record
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		types = append(types, record.Type)
+	}
+	if len(types) != 4 || types[0] != filesink.RecordTypeImpression || types[3] != filesink.RecordTypeEvent {
+		t.Error("Every impression and event should be written to the file. Actual:", types)
+	}
+}
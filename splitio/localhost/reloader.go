@@ -0,0 +1,250 @@
+package localhost
+
+import (
+	"hash/fnv"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// UpdateEvent struct describing the changes applied after the localhost files were modified
+// - Splits - Names of the splits added, modified or removed
+// - Segments - Names of the segments added, modified or removed
+// - Err - Set when the modified files could not be loaded. The previous definitions are kept in that case
+type UpdateEvent struct {
+	Splits   []string
+	Segments []string
+	Err      error
+}
+
+func newUpdateEvent() *UpdateEvent {
+	return &UpdateEvent{Splits: make([]string, 0), Segments: make([]string, 0)}
+}
+
+func (e *UpdateEvent) addSplit(name string) {
+	e.Splits = appendUnique(e.Splits, name)
+}
+
+func (e *UpdateEvent) addSegment(name string) {
+	e.Segments = appendUnique(e.Segments, name)
+}
+
+func (e *UpdateEvent) isEmpty() bool {
+	return len(e.Splits) == 0 && len(e.Segments) == 0 && e.Err == nil
+}
+
+// merge adds the changes of other to the event, keeping the latest error
+func (e *UpdateEvent) merge(other *UpdateEvent) {
+	for _, name := range other.Splits {
+		e.addSplit(name)
+	}
+	for _, name := range other.Segments {
+		e.addSegment(name)
+	}
+	if other.Err != nil {
+		e.Err = other.Err
+	}
+}
+
+func appendUnique(items []string, item string) []string {
+	index := sort.SearchStrings(items, item)
+	if index < len(items) && items[index] == item {
+		return items
+	}
+	items = append(items, "")
+	copy(items[index+1:], items[index:])
+	items[index] = item
+	return items
+}
+
+// Reloader watches the localhost files, reloading them as soon as their content changes, and notifies the changes
+// applied to its subscribers. Files are compared by content, so edits keeping the size and modification time, and
+// files read from an fs.FS, are noticed as well
+type Reloader struct {
+	fetcher         *FileSplitFetcher
+	synchronize     func() error
+	period          time.Duration
+	logger          logging.LoggerInterface
+	subscribers     map[int]chan UpdateEvent
+	nextID          int
+	stop            chan struct{}
+	done            chan struct{}
+	running         bool
+	stopped         bool
+	mutex           sync.Mutex
+	reloadMutex     sync.Mutex
+	lastFingerprint uint64
+}
+
+// NewReloader instantiates a new Reloader checking the files read by fetcher every period. synchronize must
+// fetch and store the splits through the localhost synchronizer. Calls to synchronize are serialized, so the
+// periodic synchronization of splits must be stopped once the Reloader is started
+func NewReloader(fetcher *FileSplitFetcher, synchronize func() error, period time.Duration, logger logging.LoggerInterface) *Reloader {
+	return &Reloader{
+		fetcher:     fetcher,
+		synchronize: synchronize,
+		period:      period,
+		logger:      logger,
+		subscribers: make(map[int]chan UpdateEvent),
+	}
+}
+
+// Subscribe returns a channel receiving an event every time the changes of the localhost files are applied, and a
+// function to unsubscribe. Events not yet received are merged with the following ones
+func (r *Reloader) Subscribe() (<-chan UpdateEvent, func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	updates := make(chan UpdateEvent, 1)
+	if r.stopped {
+		close(updates)
+		return updates, func() {}
+	}
+
+	id := r.nextID
+	r.nextID++
+	r.subscribers[id] = updates
+	return updates, func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if _, ok := r.subscribers[id]; ok {
+			delete(r.subscribers, id)
+			close(updates)
+		}
+	}
+}
+
+// Start starts watching the files. Changes loaded before starting are not notified. A stopped Reloader can't be
+// started again
+func (r *Reloader) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.running || r.stopped {
+		return
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	r.running = true
+	r.lastFingerprint = r.fingerprint()
+	r.fetcher.takeUpdate()
+	go r.run()
+}
+
+// Stop stops watching the files and closes the channels of every subscriber
+func (r *Reloader) Stop() {
+	r.mutex.Lock()
+	if r.stopped {
+		r.mutex.Unlock()
+		return
+	}
+	r.stopped = true
+	wasRunning := r.running
+	r.running = false
+	r.mutex.Unlock()
+
+	if wasRunning {
+		close(r.stop)
+		<-r.done
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for id, updates := range r.subscribers {
+		delete(r.subscribers, id)
+		close(updates)
+	}
+}
+
+func (r *Reloader) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.reload()
+		}
+	}
+}
+
+// reload synchronizes the splits if the content of the files changed and notifies the changes applied since the
+// last reload
+func (r *Reloader) reload() {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.Error(
+				"SDK is panicking with the following error", rec, "\n",
+				string(debug.Stack()), "\n",
+			)
+		}
+	}()
+
+	if fingerprint := r.fingerprint(); fingerprint != r.lastFingerprint {
+		r.lastFingerprint = fingerprint
+		err := r.synchronize()
+		if err != nil {
+			r.logger.Debug("Localhost: error reloading files:", err.Error())
+		}
+	}
+
+	update := r.fetcher.takeUpdate()
+	if update.isEmpty() {
+		return
+	}
+	r.notify(update)
+}
+
+func (r *Reloader) notify(update *UpdateEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, updates := range r.subscribers {
+		event := &UpdateEvent{Splits: make([]string, 0), Segments: make([]string, 0)}
+		select {
+		case pending := <-updates:
+			event.merge(&pending)
+		default:
+		}
+		event.merge(update)
+		updates <- *event
+	}
+}
+
+// fingerprint hashes the content of the files read by the fetcher, including the errors found reading them
+func (r *Reloader) fingerprint() uint64 {
+	files := r.fetcher.files
+	hasher := fnv.New64a()
+	write := func(path string) {
+		hasher.Write([]byte(path))
+		data, err := files.readFile(path)
+		if err != nil {
+			hasher.Write([]byte(err.Error()))
+		}
+		hasher.Write([]byte{0})
+		hasher.Write(data)
+		hasher.Write([]byte{0})
+	}
+
+	for _, path := range r.fetcher.paths {
+		write(path)
+	}
+	if r.fetcher.segmentDirectory == "" {
+		return hasher.Sum64()
+	}
+	paths, err := files.glob(files.join(r.fetcher.segmentDirectory, "*.json"))
+	if err != nil {
+		hasher.Write([]byte(err.Error()))
+		return hasher.Sum64()
+	}
+	for _, path := range paths {
+		write(path)
+	}
+	return hasher.Sum64()
+}
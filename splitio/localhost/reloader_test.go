@@ -0,0 +1,142 @@
+package localhost
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/storage/mutexmap"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// reloadNow reloads the files and returns the update notified, if any
+func reloadNow(t *testing.T, reloader *Reloader, updates <-chan UpdateEvent) UpdateEvent {
+	reloader.reload()
+	select {
+	case update := <-updates:
+		return update
+	default:
+		t.Error("An update should be notified")
+		return UpdateEvent{}
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_localhost")
+	if err != nil {
+		t.Error("Couldn't create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "splits.yaml")
+	segments := filepath.Join(dir, "segments")
+	os.Mkdir(segments, 0755)
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"on\"\n  s2:\n    conditions:\n      - treatment: \"on\"\n"), 0644)
+
+	splitStorage := mutexmap.NewMMSplitStorage()
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	fetcher := NewFileSplitFetcher(path, segments, segmentStorage, logging.NewLogger(nil))
+	synchronize := func() error {
+		changeNumber, _ := splitStorage.ChangeNumber()
+		changes, err := fetcher.Fetch(changeNumber)
+		if err != nil {
+			return err
+		}
+		active := make([]dtos.SplitDTO, 0, len(changes.Splits))
+		for _, split := range changes.Splits {
+			if split.Status == statusArchived {
+				splitStorage.Remove(split.Name)
+			} else {
+				active = append(active, split)
+			}
+		}
+		splitStorage.PutMany(active, changes.Till)
+		return nil
+	}
+	synchronize()
+
+	reloader := NewReloader(fetcher, synchronize, time.Hour, logging.NewLogger(nil))
+	updates, unsubscribe := reloader.Subscribe()
+	reloader.Start()
+
+	reloader.reload()
+	select {
+	case update := <-updates:
+		t.Error("Definitions loaded before starting should not be notified", update)
+	default:
+	}
+
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"off\"\n  s2:\n    conditions:\n      - treatment: \"on\"\n  s3:\n    conditions:\n      - treatment: \"on\"\n"), 0644)
+	update := reloadNow(t, reloader, updates)
+	if strings.Join(update.Splits, ",") != "s1,s3" || update.Err != nil {
+		t.Error("Modified and added splits should be notified. Actual:", update)
+	}
+	if splitStorage.Split("s1").Conditions[0].Partitions[0].Treatment != "off" {
+		t.Error("Modified split should be stored")
+	}
+
+	info, _ := os.Stat(path)
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"off\"\n  s2:\n    conditions:\n      - treatment: \"on\"\n  s3:\n    conditions:\n      - treatment: \"no\"\n"), 0644)
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+	update = reloadNow(t, reloader, updates)
+	if strings.Join(update.Splits, ",") != "s3" {
+		t.Error("Edits keeping the size and modification time should be noticed. Actual:", update)
+	}
+
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"off\"\n    trafficAllocation: 101\n"), 0644)
+	update = reloadNow(t, reloader, updates)
+	if update.Err == nil || len(update.Splits) != 0 {
+		t.Error("Invalid files should be notified with an error. Actual:", update)
+	}
+	if splitStorage.Split("s2") == nil || splitStorage.Split("s1").Conditions[0].Partitions[0].Treatment != "off" {
+		t.Error("Definitions should be kept when the file is invalid")
+	}
+
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"off\"\n"), 0644)
+	update = reloadNow(t, reloader, updates)
+	if strings.Join(update.Splits, ",") != "s2,s3" || update.Err != nil {
+		t.Error("Removed splits should be notified. Actual:", update)
+	}
+	if splitStorage.Split("s2") != nil {
+		t.Error("Removed split should not be stored")
+	}
+
+	ioutil.WriteFile(filepath.Join(segments, "employees.json"), []byte("{\"added\": [\"user1\"]}"), 0644)
+	update = reloadNow(t, reloader, updates)
+	if strings.Join(update.Segments, ",") != "employees" || len(update.Splits) != 0 {
+		t.Error("Added segments should be notified. Actual:", update)
+	}
+
+	unsubscribe()
+	if _, ok := <-updates; ok {
+		t.Error("Channel should be closed when unsubscribing")
+	}
+
+	updates, _ = reloader.Subscribe()
+	reloader.Stop()
+	reloader.Stop()
+	if _, ok := <-updates; ok {
+		t.Error("Channel should be closed when stopping")
+	}
+	if _, ok := <-func() <-chan UpdateEvent { u, _ := reloader.Subscribe(); return u }(); ok {
+		t.Error("Subscribing to a stopped reloader should return a closed channel")
+	}
+}
+
+func TestUpdateEventMerge(t *testing.T) {
+	event := newUpdateEvent()
+	event.addSplit("s2")
+	event.addSplit("s1")
+	event.addSplit("s2")
+	other := newUpdateEvent()
+	other.addSplit("s3")
+	other.addSegment("employees")
+	event.merge(other)
+	if strings.Join(event.Splits, ",") != "s1,s2,s3" || strings.Join(event.Segments, ",") != "employees" {
+		t.Error("Names should be merged sorted and without duplicates. Actual:", event)
+	}
+}
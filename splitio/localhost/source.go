@@ -0,0 +1,70 @@
+package localhost
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+)
+
+// fileSystem abstracts where localhost files are read from
+type fileSystem interface {
+	readFile(name string) ([]byte, error)
+	glob(pattern string) ([]string, error)
+	join(elem ...string) string
+}
+
+// diskFileSystem reads files from disk
+type diskFileSystem struct{}
+
+func (diskFileSystem) readFile(name string) ([]byte, error)  { return ioutil.ReadFile(name) }
+func (diskFileSystem) glob(pattern string) ([]string, error) { return filepath.Glob(pattern) }
+func (diskFileSystem) join(elem ...string) string            { return filepath.Join(elem...) }
+
+// fsFileSystem reads files from an fs.FS, such as an embed.FS
+type fsFileSystem struct {
+	fsys fs.FS
+}
+
+func (f fsFileSystem) readFile(name string) ([]byte, error)  { return fs.ReadFile(f.fsys, name) }
+func (f fsFileSystem) glob(pattern string) ([]string, error) { return fs.Glob(f.fsys, pattern) }
+func (f fsFileSystem) join(elem ...string) string            { return path.Join(elem...) }
+
+// memoryFileSystem serves data as the file called name, reading any other file from base
+type memoryFileSystem struct {
+	name string
+	data []byte
+	base fileSystem
+}
+
+func (m memoryFileSystem) readFile(name string) ([]byte, error) {
+	if name == m.name {
+		return m.data, nil
+	}
+	return m.base.readFile(name)
+}
+
+func (m memoryFileSystem) glob(pattern string) ([]string, error) { return m.base.glob(pattern) }
+func (m memoryFileSystem) join(elem ...string) string            { return m.base.join(elem...) }
+
+// fileSystemFor returns the file system reading from fsys, or from disk if it's nil
+func fileSystemFor(fsys fs.FS) fileSystem {
+	if fsys == nil {
+		return diskFileSystem{}
+	}
+	return fsFileSystem{fsys: fsys}
+}
+
+// sniffFormat guesses the extension of data read from a file without a known one
+func sniffFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		return ".json"
+	case isYAMLWithRules(trimmed) || isLegacyYAML(trimmed):
+		return ".yaml"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,323 @@
+package localhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/splitio/go-client/splitio/snapshot"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/storage"
+	"github.com/splitio/go-toolkit/datastructures/set"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// definitions holds the splits and segments read from localhost files
+type definitions struct {
+	splits   []dtos.SplitDTO
+	segments []snapshot.SegmentContent
+	// till is the change number declared by the file, or -1 if it declares none
+	till int64
+	// versioned is true if the splits carry their own change numbers
+	versioned bool
+}
+
+// FileSplitFetcher serves the splits of a localhost file to the localhost synchronizer, storing the segments
+// defined along them. It reads YAML files with targeting rules, splitChanges JSON files, snapshots exported by
+// SplitManager and the legacy YAML and line formats. Every source is read with the same parsers
+type FileSplitFetcher struct {
+	files            fileSystem
+	paths            []string
+	segmentDirectory string
+	segmentStorage   storage.SegmentStorage
+	logger           logging.LoggerInterface
+	splitsHash       uint64
+	segmentsHash     uint64
+	till             int64
+	servedSplits     map[string]uint64
+	servedSegments   map[string]uint64
+	pending          *UpdateEvent
+	lastError        string
+	mutex            sync.Mutex
+}
+
+// NewFileSplitFetcher instantiates a new FileSplitFetcher reading the file at path and, if set, the segmentChanges
+// JSON files in segmentDirectory
+func NewFileSplitFetcher(
+	path string,
+	segmentDirectory string,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) *FileSplitFetcher {
+	return newSplitFetcher(diskFileSystem{}, []string{path}, segmentDirectory, segmentStorage, logger)
+}
+
+// NewFilesSplitFetcher instantiates a new FileSplitFetcher merging the files at paths, in any of the supported
+// formats, and reading the segmentChanges JSON files in segmentDirectory, if set. Files are read from fsys, or
+// from disk if fsys is nil. Definitions of later files take precedence per split and condition
+func NewFilesSplitFetcher(
+	fsys fs.FS,
+	paths []string,
+	segmentDirectory string,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) *FileSplitFetcher {
+	return newSplitFetcher(fileSystemFor(fsys), paths, segmentDirectory, segmentStorage, logger)
+}
+
+// NewFSSplitFetcher instantiates a new FileSplitFetcher reading the file at path and, if set, the segmentChanges
+// JSON files in segmentDirectory from fsys, such as an embed.FS
+func NewFSSplitFetcher(
+	fsys fs.FS,
+	path string,
+	segmentDirectory string,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) *FileSplitFetcher {
+	return newSplitFetcher(fileSystemFor(fsys), []string{path}, segmentDirectory, segmentStorage, logger)
+}
+
+// NewDataSplitFetcher instantiates a new FileSplitFetcher serving the splits defined in data, in any of the
+// supported formats. The format is guessed from the content. If set, the segmentChanges JSON files in
+// segmentDirectory are read from fsys, or from disk if fsys is nil
+func NewDataSplitFetcher(
+	data []byte,
+	fsys fs.FS,
+	segmentDirectory string,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) *FileSplitFetcher {
+	files := memoryFileSystem{data: data, base: fileSystemFor(fsys)}
+	return newSplitFetcher(files, []string{""}, segmentDirectory, segmentStorage, logger)
+}
+
+func newSplitFetcher(
+	files fileSystem,
+	paths []string,
+	segmentDirectory string,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) *FileSplitFetcher {
+	return &FileSplitFetcher{
+		files:            files,
+		paths:            paths,
+		segmentDirectory: segmentDirectory,
+		segmentStorage:   segmentStorage,
+		logger:           logger,
+		servedSplits:     make(map[string]uint64),
+		servedSegments:   make(map[string]uint64),
+		pending:          newUpdateEvent(),
+	}
+}
+
+// Fetch returns the splits of the files if their content changed since the last call, storing the segments whose
+// content changed. Nothing is stored if any of the files is invalid
+func (f *FileSplitFetcher) Fetch(changeNumber int64) (*dtos.SplitChangesDTO, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	changes, err := f.fetch(changeNumber)
+	if err != nil {
+		if err.Error() != f.lastError {
+			f.logger.Error("Localhost: keeping the current definitions as the new ones are invalid:", err.Error())
+			f.pending.Err = err
+		}
+		f.lastError = err.Error()
+		return nil, err
+	}
+	f.lastError = ""
+	return changes, nil
+}
+
+// takeUpdate returns the changes found since the last call
+func (f *FileSplitFetcher) takeUpdate() *UpdateEvent {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	update := f.pending
+	f.pending = newUpdateEvent()
+	return update
+}
+
+func (f *FileSplitFetcher) fetch(changeNumber int64) (*dtos.SplitChangesDTO, error) {
+	contents := make([][]byte, 0, len(f.paths))
+	files := make([]*definitions, 0, len(f.paths))
+	for _, path := range f.paths {
+		data, err := f.files.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		defs, err := f.parse(path, data)
+		if err != nil {
+			return nil, fileError(path, data, err)
+		}
+		contents = append(contents, data)
+		files = append(files, defs)
+	}
+
+	defs := mergeDefinitions(files)
+	if f.segmentDirectory != "" {
+		segments, err := readSegmentDirectory(f.files, f.segmentDirectory)
+		if err != nil {
+			return nil, err
+		}
+		defs.segments = mergeSegments(defs.segments, segments)
+	}
+
+	splitsHash := hashOf(bytes.Join(contents, []byte{0}))
+	if splitsHash == f.splitsHash && changeNumber >= f.till {
+		err := f.storeSegments(defs.segments)
+		if err != nil {
+			return nil, err
+		}
+		return &dtos.SplitChangesDTO{Splits: []dtos.SplitDTO{}, Since: changeNumber, Till: changeNumber}, nil
+	}
+
+	till := changeNumber
+	if f.till > till {
+		till = f.till
+	}
+	till++
+	if defs.till > till {
+		till = defs.till
+	}
+
+	splits := defs.splits
+	changed := make([]string, 0)
+	current := make(map[string]uint64, len(splits))
+	for index := range splits {
+		if !defs.versioned {
+			splits[index].ChangeNumber = till
+		}
+		hash := hashOfSplit(splits[index])
+		if previous, ok := f.servedSplits[splits[index].Name]; !ok || previous != hash {
+			changed = append(changed, splits[index].Name)
+		}
+		current[splits[index].Name] = hash
+	}
+	for name := range f.servedSplits {
+		if _, ok := current[name]; !ok {
+			splits = append(splits, dtos.SplitDTO{Name: name, Status: statusArchived, ChangeNumber: till})
+			changed = append(changed, name)
+		}
+	}
+
+	// Segments are stored last, so that nothing is applied unless the whole fetch succeeds
+	err := f.storeSegments(defs.segments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range changed {
+		f.pending.addSplit(name)
+	}
+	f.splitsHash = splitsHash
+	f.till = till
+	f.servedSplits = current
+	return &dtos.SplitChangesDTO{Splits: splits, Since: changeNumber, Till: till}, nil
+}
+
+// parse reads the definitions of a split file
+func (f *FileSplitFetcher) parse(path string, data []byte) (*definitions, error) {
+	defs := &definitions{till: -1}
+
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension != ".json" && extension != ".yaml" && extension != ".yml" {
+		extension = sniffFormat(data)
+	}
+	switch {
+	case extension == ".json" && isSnapshot(data):
+		s, err := snapshot.Read(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defs.splits = s.Splits
+		defs.segments = s.Segments
+		defs.till = s.SplitsChangeNumber
+		defs.versioned = true
+	case extension == ".json":
+		splitChanges, err := parseSplitChanges(data)
+		if err != nil {
+			return nil, err
+		}
+		defs.splits = splitChanges.Splits
+		defs.till = splitChanges.Till
+		defs.versioned = true
+	case (extension == ".yaml" || extension == ".yml") && isYAMLWithRules(data):
+		splits, err := parseYAMLWithRules(data, f.logger)
+		if err != nil {
+			return nil, err
+		}
+		defs.splits = splits
+	case extension == ".yaml" || extension == ".yml":
+		splits, err := parseLegacyYAML(data, f.logger)
+		if err != nil {
+			return nil, err
+		}
+		defs.splits = splits
+	default:
+		splits, err := parseLegacyLines(data, f.logger)
+		if err != nil {
+			return nil, err
+		}
+		defs.splits = splits
+	}
+	return defs, nil
+}
+
+// storeSegments replaces the stored segments if their content changed, emptying the ones no longer defined
+func (f *FileSplitFetcher) storeSegments(segments []snapshot.SegmentContent) error {
+	encoded, err := json.Marshal(segments)
+	if err != nil {
+		return err
+	}
+	segmentsHash := hashOf(encoded)
+	if segmentsHash == f.segmentsHash {
+		return nil
+	}
+
+	err = (&snapshot.Snapshot{Segments: segments}).LoadSegments(f.segmentStorage)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]uint64, len(segments))
+	for _, segment := range segments {
+		encoded, _ := json.Marshal(segment)
+		hash := hashOf(encoded)
+		if previous, ok := f.servedSegments[segment.Name]; !ok || previous != hash {
+			f.pending.addSegment(segment.Name)
+		}
+		current[segment.Name] = hash
+	}
+	for name := range f.servedSegments {
+		if _, ok := current[name]; ok {
+			continue
+		}
+		if keys := f.segmentStorage.Keys(name); keys != nil {
+			changeNumber, _ := f.segmentStorage.ChangeNumber(name)
+			f.segmentStorage.Update(name, set.NewSet(), keys, changeNumber)
+		}
+		f.pending.addSegment(name)
+	}
+
+	f.segmentsHash = segmentsHash
+	f.servedSegments = current
+	return nil
+}
+
+// hashOfSplit hashes the definition of a split regardless of its change number
+func hashOfSplit(split dtos.SplitDTO) uint64 {
+	split.ChangeNumber = 0
+	encoded, _ := json.Marshal(split)
+	return hashOf(encoded)
+}
+
+func hashOf(data []byte) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write(data)
+	return hasher.Sum64()
+}
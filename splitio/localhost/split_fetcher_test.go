@@ -0,0 +1,234 @@
+package localhost
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/splitio/go-split-commons/storage/mutexmap"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestFileSplitFetcherYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_localhost")
+	if err != nil {
+		t.Error("Couldn't create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "splits.yaml")
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"on\"\n  s2:\n    conditions:\n      - treatment: \"on\"\n"), 0644)
+
+	fetcher := NewFileSplitFetcher(path, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil))
+
+	changes, err := fetcher.Fetch(-1)
+	if err != nil || changes.Since != -1 || changes.Till != 1 || len(changes.Splits) != 2 || changes.Splits[0].ChangeNumber != 1 {
+		t.Error("Splits should be served on first fetch", changes, err)
+	}
+	changes, _ = fetcher.Fetch(changes.Till)
+	if changes.Since != changes.Till || len(changes.Splits) != 0 {
+		t.Error("No changes should be served while the file does not change")
+	}
+
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    conditions:\n      - treatment: \"off\"\n"), 0644)
+	changes, _ = fetcher.Fetch(1)
+	if changes.Till != 2 || len(changes.Splits) != 2 {
+		t.Error("Changed file should be served again", changes)
+		return
+	}
+	if changes.Splits[0].Name != "s1" || changes.Splits[1].Name != "s2" || changes.Splits[1].Status != "ARCHIVED" {
+		t.Error("Removed splits should be archived", changes.Splits)
+	}
+
+	ioutil.WriteFile(path, []byte("splits:\n  s1:\n    trafficAllocation: -1\n"), 0644)
+	if _, err = fetcher.Fetch(2); err == nil {
+		t.Error("Invalid files should return error")
+	}
+
+	ioutil.WriteFile(path, []byte("- s1:\n    treatment: \"on\"\n"), 0644)
+	changes, _ = fetcher.Fetch(2)
+	if changes == nil || changes.Till != 3 || len(changes.Splits) != 1 || changes.Splits[0].Conditions[0].Partitions[0].Treatment != "on" {
+		t.Error("Legacy files should be read by the same fetcher", changes)
+	}
+}
+
+func TestFileSplitFetcherSplitChangesAndSegments(t *testing.T) {
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	fetcher := NewFileSplitFetcher(
+		"../../testdata/localhost/split_changes.json",
+		"../../testdata/localhost/segments",
+		segmentStorage,
+		logging.NewLogger(nil),
+	)
+
+	changes, err := fetcher.Fetch(-1)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	if changes.Till != 1494593336752 || len(changes.Splits) != 1 || changes.Splits[0].ChangeNumber != 1494593336752 {
+		t.Error("Change numbers of the file should be kept", changes)
+	}
+
+	employees := segmentStorage.Keys("employees")
+	if employees == nil || employees.Size() != 2 || employees.Has("user3") {
+		t.Error("Segment keys should be the added ones minus the removed ones")
+	}
+	if changeNumber, _ := segmentStorage.ChangeNumber("employees"); changeNumber != 1489542661161 {
+		t.Error("Segment change number should be stored. Actual:", changeNumber)
+	}
+	if beta := segmentStorage.Keys("beta_testers"); beta == nil || !beta.Has("user4") {
+		t.Error("Segments without name should be named after their file")
+	}
+
+	changes, _ = fetcher.Fetch(changes.Till)
+	if len(changes.Splits) != 0 {
+		t.Error("No changes should be served while the file does not change")
+	}
+}
+
+func TestFileSplitFetcherSegmentErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_localhost")
+	if err != nil {
+		t.Error("Couldn't create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte("{\"name\": \"employees\", \"added\": [\"user1\"]}"), 0644)
+
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	fetcher := NewFileSplitFetcher("../../testdata/localhost/split_changes.json", dir, segmentStorage, logging.NewLogger(nil))
+	if _, err = fetcher.Fetch(-1); err != nil {
+		t.Error("It should not return error. Actual:", err)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte("{\"name\": \"employees\", \"added\": [\"user2\"]}"), 0644)
+	_, err = fetcher.Fetch(-1)
+	if err == nil || err.Error() != filepath.Join(dir, "b.json")+": segment employees is already defined in "+filepath.Join(dir, "a.json") {
+		t.Error("Duplicated segments should be rejected. Actual:", err)
+	}
+	if !segmentStorage.Keys("employees").Has("user1") {
+		t.Error("Stored segments should be kept when files are invalid")
+	}
+
+	os.Remove(filepath.Join(dir, "b.json"))
+	os.Remove(filepath.Join(dir, "a.json"))
+	fetcher.Fetch(-1)
+	if segmentStorage.Keys("employees").Size() != 0 {
+		t.Error("Segments no longer defined should be emptied")
+	}
+}
+
+func TestDataSplitFetcher(t *testing.T) {
+	formats := map[string]string{
+		"lines":       "feature on\n",
+		"legacy yaml": "- feature:\n    treatment: \"on\"\n",
+		"yaml":        "splits:\n  feature:\n    conditions:\n      - treatment: \"on\"\n",
+		"json":        "{\"splits\": [{\"name\": \"feature\", \"status\": \"ACTIVE\", \"changeNumber\": 5}], \"since\": -1, \"till\": 5}",
+	}
+	for format, data := range formats {
+		fetcher := NewDataSplitFetcher([]byte(data), nil, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil))
+		changes, err := fetcher.Fetch(-1)
+		if err != nil || len(changes.Splits) != 1 || changes.Splits[0].Name != "feature" {
+			t.Error("Splits should be read from", format, "data. Actual:", changes, err)
+		}
+	}
+}
+
+func TestFSSplitFetcher(t *testing.T) {
+	fsys := fstest.MapFS{
+		"flags/splits.yaml":       {Data: []byte("splits:\n  employees_only:\n    conditions:\n      - matchers:\n          - type: IN_SEGMENT\n            segment: employees\n        treatment: \"on\"\n")},
+		"segments/employees.json": {Data: []byte("{\"name\": \"employees\", \"added\": [\"user1\"], \"till\": 10}")},
+	}
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	fetcher := NewFSSplitFetcher(fsys, "flags/splits.yaml", "segments", segmentStorage, logging.NewLogger(nil))
+
+	changes, err := fetcher.Fetch(-1)
+	if err != nil || len(changes.Splits) != 1 || changes.Splits[0].Name != "employees_only" {
+		t.Error("Splits should be read from the file system. Actual:", changes, err)
+	}
+	if keys := segmentStorage.Keys("employees"); keys == nil || !keys.Has("user1") {
+		t.Error("Segments should be read from the file system")
+	}
+
+	data := NewDataSplitFetcher([]byte("feature on\n"), fsys, "segments", segmentStorage, logging.NewLogger(nil))
+	changes, err = data.Fetch(-1)
+	if err != nil || len(changes.Splits) != 1 || changes.Splits[0].Name != "feature" {
+		t.Error("Data should be served as the split file. Actual:", changes, err)
+	}
+}
+
+func TestFilesSplitFetcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_localhost")
+	if err != nil {
+		t.Error("Couldn't create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	shared := filepath.Join(dir, "shared.json")
+	local := filepath.Join(dir, "local.yaml")
+	lines := filepath.Join(dir, "lines.split")
+	ioutil.WriteFile(shared, []byte("{\"splits\": [{\"name\": \"feature\", \"status\": \"ACTIVE\", \"defaultTreatment\": \"off\", \"changeNumber\": 5}], \"since\": -1, \"till\": 5}"), 0644)
+	ioutil.WriteFile(local, []byte("splits:\n  feature:\n    conditions:\n      - keys: [\"developer\"]\n        treatment: \"on\"\n"), 0644)
+	ioutil.WriteFile(lines, []byte("other on\n"), 0644)
+
+	fetcher := NewFilesSplitFetcher(nil, []string{shared, local, lines}, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil))
+	changes, err := fetcher.Fetch(-1)
+	if err != nil || len(changes.Splits) != 2 || changes.Splits[0].Name != "feature" || changes.Splits[1].Name != "other" {
+		t.Error("Splits of every file should be served. Actual:", changes, err)
+		return
+	}
+	if len(changes.Splits[0].Conditions) != 1 || changes.Splits[0].ChangeNumber != changes.Till {
+		t.Error("Later files should take precedence and change numbers should be set by the fetcher")
+	}
+
+	ioutil.WriteFile(local, []byte("splits:\n  feature:\n    conditions:\n      - keys: [\"developer\"]\n     treatment: \"on\"\n"), 0644)
+	_, err = fetcher.Fetch(changes.Till)
+	if err == nil || !strings.HasPrefix(err.Error(), local+": yaml: line 4") {
+		t.Error("Errors should name the file and line. Actual:", err)
+	}
+
+	ioutil.WriteFile(lines, []byte("other on\nbroken\n"), 0644)
+	ioutil.WriteFile(local, []byte("splits: {}\n"), 0644)
+	_, err = fetcher.Fetch(changes.Till)
+	if err == nil || err.Error() != lines+": line 2: expected a split name and a treatment. Actual is: broken" {
+		t.Error("Errors should name the file and line. Actual:", err)
+	}
+}
+
+func TestEverySourceReadsTheSameSplits(t *testing.T) {
+	fixtures := []string{"splits.yaml", "splits_with_rules.yaml", "localhost/split_changes.json", "localhost/splits.split"}
+	for _, fixture := range fixtures {
+		path := filepath.Join("../../testdata", fixture)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		sources := map[string]*FileSplitFetcher{
+			"file":  NewFileSplitFetcher(path, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil)),
+			"files": NewFilesSplitFetcher(nil, []string{path}, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil)),
+			"fs":    NewFSSplitFetcher(os.DirFS("../../testdata"), fixture, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil)),
+			"data":  NewDataSplitFetcher(data, nil, "", mutexmap.NewMMSegmentStorage(), logging.NewLogger(nil)),
+		}
+		var expected []byte
+		for source, fetcher := range sources {
+			changes, err := fetcher.Fetch(-1)
+			if err != nil || len(changes.Splits) == 0 {
+				t.Error("Splits should be read from", fixture, "as", source, "Actual:", changes, err)
+				continue
+			}
+			encoded, _ := json.Marshal(changes)
+			if expected == nil {
+				expected = encoded
+			} else if string(encoded) != string(expected) {
+				t.Error("Every source should read the same splits from", fixture, "Actual for", source, ":", string(encoded))
+			}
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package localhost
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/splitio/go-client/splitio/engine/grammar"
+	"github.com/splitio/go-client/splitio/engine/grammar/matchers"
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-toolkit/logging"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	defaultTrafficType      = "user"
+	defaultDefaultTreatment = "control"
+	defaultTrafficAlloc     = 100
+	algoMurmur              = 2
+	statusActive            = "ACTIVE"
+	statusArchived          = "ARCHIVED"
+	combinerAnd             = "AND"
+	dataTypeNumber          = "NUMBER"
+	labelWhitelisted        = "whitelisted"
+	labelDefaultRule        = "default rule"
+	labelCustomRule         = "custom rule"
+)
+
+// yamlFile is the root of the localhost YAML schema supporting targeting rules. Files without a top level
+// "splits" mapping are read in the legacy format, which maps treatments to lists of keys
+type yamlFile struct {
+	Splits map[string]yamlSplit `yaml:"splits"`
+}
+
+// yamlSplit describes a split
+// - TrafficType - Traffic type of the split. Defaults to 'user'
+// - Killed - When true every key gets the default treatment
+// - DefaultTreatment - Treatment for keys not matching any condition. Defaults to 'control'
+// - TrafficAllocation - Percentage of the traffic evaluated against rollout conditions. Defaults to 100
+// - Configurations - Config of each treatment
+// - Conditions - Conditions evaluated in order until one matches
+type yamlSplit struct {
+	TrafficType       string            `yaml:"trafficType"`
+	Killed            bool              `yaml:"killed"`
+	DefaultTreatment  string            `yaml:"defaultTreatment"`
+	TrafficAllocation *int              `yaml:"trafficAllocation"`
+	Configurations    map[string]string `yaml:"configurations"`
+	Conditions        []yamlCondition   `yaml:"conditions"`
+}
+
+// yamlCondition describes a condition. Conditions with keys target those keys only, conditions with matchers
+// target the keys matching all of them and conditions with neither match every key
+// - Keys - Keys targeted by the condition
+// - Matchers - Matchers combined with AND
+// - Treatment - Treatment returned to every matching key
+// - Treatments - Weighted treatments to split the matching keys. Sizes must add up to 100
+// - Label - Label of the impressions generated by the condition
+type yamlCondition struct {
+	Keys       []string        `yaml:"keys"`
+	Matchers   []yamlMatcher   `yaml:"matchers"`
+	Treatment  string          `yaml:"treatment"`
+	Treatments []yamlPartition `yaml:"treatments"`
+	Label      string          `yaml:"label"`
+}
+
+// yamlPartition is a treatment along with the percentage of keys receiving it
+type yamlPartition struct {
+	Treatment string `yaml:"treatment"`
+	Size      int    `yaml:"size"`
+}
+
+// yamlMatcher describes a matcher using the matcher types of the engine
+// - Type - Matcher type, such as 'GREATER_THAN_OR_EQUAL_TO' or 'IN_SEGMENT'
+// - Attribute - Attribute matched. When empty the key is matched
+// - Negate - Inverts the result of the matcher
+// - Value - Number, boolean or regular expression for single value matchers
+// - Values - Strings for whitelist, set and string matchers
+// - Start, End - Bounds of BETWEEN matchers
+// - DataType - Either 'NUMBER' or 'DATETIME' for numeric matchers. Defaults to 'NUMBER'
+// - Segment - Segment for IN_SEGMENT matchers
+// - Split, SplitTreatments - Split and treatments for IN_SPLIT_TREATMENT matchers
+type yamlMatcher struct {
+	Type            string      `yaml:"type"`
+	Attribute       string      `yaml:"attribute"`
+	Negate          bool        `yaml:"negate"`
+	Value           interface{} `yaml:"value"`
+	Values          []string    `yaml:"values"`
+	Start           *int64      `yaml:"start"`
+	End             *int64      `yaml:"end"`
+	DataType        string      `yaml:"dataType"`
+	Segment         string      `yaml:"segment"`
+	Split           string      `yaml:"split"`
+	SplitTreatments []string    `yaml:"splitTreatments"`
+}
+
+// isYAMLWithRules returns true if the content follows the localhost YAML schema supporting targeting rules
+func isYAMLWithRules(data []byte) bool {
+	var root map[string]interface{}
+	if yaml.Unmarshal(data, &root) != nil {
+		return false
+	}
+	_, ok := root["splits"]
+	return ok
+}
+
+// parseYAMLWithRules translates the localhost YAML schema supporting targeting rules into split definitions
+func parseYAMLWithRules(data []byte, logger logging.LoggerInterface) ([]dtos.SplitDTO, error) {
+	var file yamlFile
+	err := yaml.UnmarshalStrict(data, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	splits := make([]dtos.SplitDTO, 0, len(file.Splits))
+	for name, split := range file.Splits {
+		splitDTO, err := split.toDTO(name, logger)
+		if err != nil {
+			return nil, fmt.Errorf("split %s: %s", name, err.Error())
+		}
+		splits = append(splits, *splitDTO)
+	}
+	sort.Slice(splits, func(i, j int) bool { return splits[i].Name < splits[j].Name })
+	return splits, nil
+}
+
+func (s *yamlSplit) toDTO(name string, logger logging.LoggerInterface) (*dtos.SplitDTO, error) {
+	splitDTO := &dtos.SplitDTO{
+		Name:                  name,
+		TrafficTypeName:       s.TrafficType,
+		Killed:                s.Killed,
+		DefaultTreatment:      s.DefaultTreatment,
+		TrafficAllocation:     defaultTrafficAlloc,
+		TrafficAllocationSeed: seedFor("traffic:" + name),
+		Seed:                  seedFor(name),
+		Status:                statusActive,
+		Algo:                  algoMurmur,
+		Configurations:        s.Configurations,
+		Conditions:            make([]dtos.ConditionDTO, 0, len(s.Conditions)),
+	}
+	if splitDTO.TrafficTypeName == "" {
+		splitDTO.TrafficTypeName = defaultTrafficType
+	}
+	if splitDTO.DefaultTreatment == "" {
+		splitDTO.DefaultTreatment = defaultDefaultTreatment
+	}
+	if s.TrafficAllocation != nil {
+		if *s.TrafficAllocation < 0 || *s.TrafficAllocation > 100 {
+			return nil, fmt.Errorf("trafficAllocation must be between 0 and 100. Actual is: %d", *s.TrafficAllocation)
+		}
+		splitDTO.TrafficAllocation = *s.TrafficAllocation
+	}
+
+	for index, condition := range s.Conditions {
+		conditionDTO, err := condition.toDTO(splitDTO.TrafficTypeName, logger)
+		if err != nil {
+			return nil, fmt.Errorf("condition %d: %s", index+1, err.Error())
+		}
+		splitDTO.Conditions = append(splitDTO.Conditions, *conditionDTO)
+	}
+	return splitDTO, nil
+}
+
+func (c *yamlCondition) toDTO(trafficType string, logger logging.LoggerInterface) (*dtos.ConditionDTO, error) {
+	partitions, err := c.partitions()
+	if err != nil {
+		return nil, err
+	}
+
+	conditionDTO := &dtos.ConditionDTO{
+		ConditionType: grammar.ConditionTypeRollout,
+		MatcherGroup:  dtos.MatcherGroupDTO{Combiner: combinerAnd, Matchers: make([]dtos.MatcherDTO, 0)},
+		Partitions:    partitions,
+		Label:         c.Label,
+	}
+
+	switch {
+	case len(c.Keys) > 0 && len(c.Matchers) > 0:
+		return nil, errors.New("keys and matchers cannot be combined in the same condition")
+	case len(c.Keys) > 0:
+		conditionDTO.ConditionType = grammar.ConditionTypeWhitelist
+		conditionDTO.MatcherGroup.Matchers = append(conditionDTO.MatcherGroup.Matchers, dtos.MatcherDTO{
+			KeySelector: &dtos.KeySelectorDTO{TrafficType: trafficType},
+			MatcherType: matchers.MatcherTypeWhitelist,
+			Whitelist:   &dtos.WhitelistMatcherDataDTO{Whitelist: c.Keys},
+		})
+		if conditionDTO.Label == "" {
+			conditionDTO.Label = labelWhitelisted
+		}
+	case len(c.Matchers) > 0:
+		for index, matcher := range c.Matchers {
+			matcherDTO, err := matcher.toDTO(trafficType)
+			if err == nil {
+				_, err = matchers.BuildMatcher(matcherDTO, nil, logger)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("matcher %d: %s", index+1, err.Error())
+			}
+			conditionDTO.MatcherGroup.Matchers = append(conditionDTO.MatcherGroup.Matchers, *matcherDTO)
+		}
+		if conditionDTO.Label == "" {
+			conditionDTO.Label = labelCustomRule
+		}
+	default:
+		conditionDTO.MatcherGroup.Matchers = append(conditionDTO.MatcherGroup.Matchers, dtos.MatcherDTO{
+			KeySelector: &dtos.KeySelectorDTO{TrafficType: trafficType},
+			MatcherType: matchers.MatcherTypeAllKeys,
+		})
+		if conditionDTO.Label == "" {
+			conditionDTO.Label = labelDefaultRule
+		}
+	}
+	return conditionDTO, nil
+}
+
+func (c *yamlCondition) partitions() ([]dtos.PartitionDTO, error) {
+	if c.Treatment != "" && len(c.Treatments) > 0 {
+		return nil, errors.New("treatment and treatments cannot be combined in the same condition")
+	}
+	if c.Treatment != "" {
+		return []dtos.PartitionDTO{{Treatment: c.Treatment, Size: 100}}, nil
+	}
+	if len(c.Treatments) == 0 {
+		return nil, errors.New("treatment or treatments must be set")
+	}
+
+	total := 0
+	partitions := make([]dtos.PartitionDTO, 0, len(c.Treatments))
+	for _, partition := range c.Treatments {
+		if partition.Treatment == "" {
+			return nil, errors.New("every weighted treatment must have a name")
+		}
+		if partition.Size < 0 {
+			return nil, fmt.Errorf("size of treatment %s must be >= 0. Actual is: %d", partition.Treatment, partition.Size)
+		}
+		total += partition.Size
+		partitions = append(partitions, dtos.PartitionDTO{Treatment: partition.Treatment, Size: partition.Size})
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("sizes of treatments must add up to 100. Actual is: %d", total)
+	}
+	return partitions, nil
+}
+
+func (m *yamlMatcher) toDTO(trafficType string) (*dtos.MatcherDTO, error) {
+	if m.Type == "" {
+		return nil, errors.New("type must be set")
+	}
+
+	matcherDTO := &dtos.MatcherDTO{
+		KeySelector: &dtos.KeySelectorDTO{TrafficType: trafficType},
+		MatcherType: m.Type,
+		Negate:      m.Negate,
+	}
+	if m.Attribute != "" {
+		attribute := m.Attribute
+		matcherDTO.KeySelector.Attribute = &attribute
+	}
+	dataType := m.DataType
+	if dataType == "" {
+		dataType = dataTypeNumber
+	}
+
+	switch m.Type {
+	case matchers.MatcherTypeEqualTo, matchers.MatcherTypeGreaterThanOrEqualTo, matchers.MatcherTypeLessThanOrEqualTo:
+		value, ok := m.Value.(int)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be an integer", m.Type)
+		}
+		matcherDTO.UnaryNumeric = &dtos.UnaryNumericMatcherDataDTO{DataType: dataType, Value: int64(value)}
+	case matchers.MatcherTypeBetween:
+		if m.Start == nil || m.End == nil {
+			return nil, fmt.Errorf("start and end must be set for %s", m.Type)
+		}
+		matcherDTO.Between = &dtos.BetweenMatcherDataDTO{DataType: dataType, Start: *m.Start, End: *m.End}
+	case matchers.MatcherTypeEqualToBoolean:
+		value, ok := m.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a boolean", m.Type)
+		}
+		matcherDTO.Boolean = &value
+	case matchers.MatcherTypeMatchesString:
+		value, ok := m.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a string", m.Type)
+		}
+		matcherDTO.String = &value
+	case matchers.MatcherTypeInSegment:
+		if m.Segment == "" {
+			return nil, fmt.Errorf("segment must be set for %s", m.Type)
+		}
+		matcherDTO.UserDefinedSegment = &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: m.Segment}
+	case matchers.MatcherTypeInSplitTreatment:
+		if m.Split == "" || len(m.SplitTreatments) == 0 {
+			return nil, fmt.Errorf("split and splitTreatments must be set for %s", m.Type)
+		}
+		matcherDTO.Dependency = &dtos.DependencyMatcherDataDTO{Split: m.Split, Treatments: m.SplitTreatments}
+	case matchers.MatcherTypeAllKeys:
+	default:
+		if len(m.Values) == 0 {
+			return nil, fmt.Errorf("values must be set for %s", m.Type)
+		}
+		matcherDTO.Whitelist = &dtos.WhitelistMatcherDataDTO{Whitelist: m.Values}
+	}
+	return matcherDTO, nil
+}
+
+// seedFor derives a stable seed from a name so that percentages don't change between runs
+func seedFor(name string) int64 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(name))
+	return int64(int32(hasher.Sum32()))
+}
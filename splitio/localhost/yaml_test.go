@@ -0,0 +1,88 @@
+package localhost
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestParseYAMLWithRules(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testdata/splits_with_rules.yaml")
+	if err != nil {
+		t.Error("Couldn't read test file:", err)
+		return
+	}
+	if !isYAMLWithRules(data) {
+		t.Error("File should be detected as YAML with rules")
+	}
+
+	splits, err := parseYAMLWithRules(data, logging.NewLogger(nil))
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	if len(splits) != 3 || splits[0].Name != "checkout_flow" || splits[1].Name != "legacy_banner" || splits[2].Name != "new_pricing" {
+		t.Error("Splits should be sorted by name")
+		return
+	}
+
+	checkout := splits[0]
+	if checkout.DefaultTreatment != "off" || checkout.TrafficAllocation != 100 || checkout.Algo != 2 || checkout.Status != "ACTIVE" {
+		t.Error("Unexpected split settings", checkout)
+	}
+	if len(checkout.Conditions) != 3 {
+		t.Error("Three conditions should be translated")
+		return
+	}
+	if checkout.Conditions[0].ConditionType != "WHITELIST" || checkout.Conditions[0].MatcherGroup.Matchers[0].Whitelist.Whitelist[0] != "qa_user" {
+		t.Error("Keys should be translated into a whitelist condition")
+	}
+	matchers := checkout.Conditions[1].MatcherGroup.Matchers
+	if len(matchers) != 2 || *matchers[0].KeySelector.Attribute != "age" || matchers[0].UnaryNumeric.Value != 18 || matchers[0].UnaryNumeric.DataType != "NUMBER" {
+		t.Error("Attribute matchers should be translated", matchers)
+	}
+	if checkout.Conditions[1].Label != "adults in ar or us" || checkout.Conditions[1].ConditionType != "ROLLOUT" {
+		t.Error("Unexpected condition settings", checkout.Conditions[1])
+	}
+	if checkout.Conditions[2].MatcherGroup.Matchers[0].MatcherType != "ALL_KEYS" || checkout.Conditions[2].Partitions[1].Size != 100 {
+		t.Error("Conditions without keys or matchers should match all keys")
+	}
+
+	if !splits[1].Killed || splits[1].DefaultTreatment != "hidden" {
+		t.Error("Killed split should be translated")
+	}
+	if splits[2].TrafficAllocation != 50 || splits[2].TrafficAllocationSeed == splits[2].Seed {
+		t.Error("Traffic allocation should be translated with its own seed")
+	}
+
+	again, _ := parseYAMLWithRules(data, logging.NewLogger(nil))
+	if again[2].Seed != splits[2].Seed {
+		t.Error("Seeds should be stable between runs")
+	}
+}
+
+func TestParseYAMLWithRulesErrors(t *testing.T) {
+	cases := map[string]string{
+		"splits:\n  s1:\n    conditions:\n      - matchers:\n          - type: NOT_A_MATCHER\n            values: [a]\n        treatment: on\n":           "split s1: condition 1: matcher 1: Matcher not found",
+		"splits:\n  s1:\n    conditions:\n      - matchers:\n          - type: GREATER_THAN_OR_EQUAL_TO\n            value: abc\n        treatment: on\n": "split s1: condition 1: matcher 1: value of GREATER_THAN_OR_EQUAL_TO must be an integer",
+		"splits:\n  s1:\n    conditions:\n      - treatments:\n          - treatment: on\n            size: 60\n":                                         "split s1: condition 1: sizes of treatments must add up to 100. Actual is: 60",
+		"splits:\n  s1:\n    conditions:\n      - keys: [a]\n":                                                                                            "split s1: condition 1: treatment or treatments must be set",
+		"splits:\n  s1:\n    trafficAllocation: 150\n":                                                                                                    "split s1: trafficAllocation must be between 0 and 100. Actual is: 150",
+	}
+	for data, expected := range cases {
+		_, err := parseYAMLWithRules([]byte(data), logging.NewLogger(nil))
+		if err == nil || err.Error() != expected {
+			t.Error("Unexpected error. Expected:", expected, "Actual:", err)
+		}
+	}
+
+	_, err := parseYAMLWithRules([]byte("splits:\n  s1:\n    unknown: true\n"), logging.NewLogger(nil))
+	if err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Error("Unknown fields should be rejected. Actual:", err)
+	}
+	if isYAMLWithRules([]byte("- my_feature:\n    treatment: \"on\"\n")) {
+		t.Error("Legacy YAML should not be detected as YAML with rules")
+	}
+}
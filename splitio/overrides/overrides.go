@@ -0,0 +1,174 @@
+package overrides
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Override struct describing a treatment forced for a feature
+// - Feature - Name of the feature
+// - Treatment - Treatment returned by every evaluation of the feature matching the override
+// - Config - Configuration returned along the treatment, if any
+// - Keys - Matching keys the override applies to. Empty when it applies to every key
+type Override struct {
+	Feature   string   `json:"feature"`
+	Treatment string   `json:"treatment"`
+	Config    *string  `json:"config"`
+	Keys      []string `json:"keys"`
+}
+
+type forcedTreatment struct {
+	treatment string
+	config    *string
+}
+
+type featureOverrides struct {
+	all    *forcedTreatment
+	byKeys map[string]forcedTreatment
+}
+
+// Store holds the treatments forced at runtime. Overrides take precedence over the split definitions in every
+// operation mode
+type Store struct {
+	features map[string]*featureOverrides
+	mutex    sync.RWMutex
+}
+
+// NewStore instantiates a new empty Store
+func NewStore() *Store {
+	return &Store{features: make(map[string]*featureOverrides)}
+}
+
+// Set forces treatment and config for the given matching keys of feature, or for every key if none is passed.
+// Overrides for specific keys take precedence over the ones for every key
+func (s *Store) Set(feature string, treatment string, config *string, keys ...string) error {
+	if strings.TrimSpace(feature) == "" {
+		return errors.New("you passed an empty feature, feature must be a non-empty string")
+	}
+	if strings.TrimSpace(treatment) == "" {
+		return errors.New("you passed an empty treatment, treatment must be a non-empty string")
+	}
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return errors.New("you passed an empty key, keys must be non-empty strings")
+		}
+	}
+
+	forced := forcedTreatment{treatment: treatment, config: copyConfig(config)}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	current, ok := s.features[feature]
+	if !ok {
+		current = &featureOverrides{byKeys: make(map[string]forcedTreatment)}
+		s.features[feature] = current
+	}
+	if len(keys) == 0 {
+		current.all = &forced
+		return nil
+	}
+	for _, key := range keys {
+		current.byKeys[key] = forced
+	}
+	return nil
+}
+
+// Clear removes every override of feature
+func (s *Store) Clear(feature string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.features, feature)
+}
+
+// ClearAll removes every override
+func (s *Store) ClearAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.features = make(map[string]*featureOverrides)
+}
+
+// Get returns the treatment and config forced for feature and the matching key, if any
+func (s *Store) Get(feature string, key string) (string, *string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	current, ok := s.features[feature]
+	if !ok {
+		return "", nil, false
+	}
+	if forced, ok := current.byKeys[key]; ok {
+		return forced.treatment, copyConfig(forced.config), true
+	}
+	if current.all != nil {
+		return current.all.treatment, copyConfig(current.all.config), true
+	}
+	return "", nil, false
+}
+
+// List returns the overrides sorted by feature. Keys sharing treatment and config are grouped in one override,
+// listed after the one applying to every key
+func (s *Store) List() []Override {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	features := make([]string, 0, len(s.features))
+	for feature := range s.features {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	list := make([]Override, 0, len(features))
+	for _, feature := range features {
+		current := s.features[feature]
+		if current.all != nil {
+			list = append(list, Override{
+				Feature:   feature,
+				Treatment: current.all.treatment,
+				Config:    copyConfig(current.all.config),
+				Keys:      make([]string, 0),
+			})
+		}
+
+		keys := make([]string, 0, len(current.byKeys))
+		for key := range current.byKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		groups := make([]*Override, 0)
+		for _, key := range keys {
+			forced := current.byKeys[key]
+			var group *Override
+			for _, candidate := range groups {
+				if candidate.Treatment == forced.treatment && sameConfig(candidate.Config, forced.config) {
+					group = candidate
+					break
+				}
+			}
+			if group == nil {
+				group = &Override{Feature: feature, Treatment: forced.treatment, Config: copyConfig(forced.config)}
+				groups = append(groups, group)
+			}
+			group.Keys = append(group.Keys, key)
+		}
+		for _, group := range groups {
+			list = append(list, *group)
+		}
+	}
+	return list
+}
+
+func copyConfig(config *string) *string {
+	if config == nil {
+		return nil
+	}
+	value := *config
+	return &value
+}
+
+func sameConfig(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,50 @@
+package overrides
+
+import "testing"
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	config := "{\"color\": \"red\"}"
+
+	if store.Set("", "on", nil) == nil || store.Set("feature", " ", nil) == nil || store.Set("feature", "on", nil, "") == nil {
+		t.Error("Empty feature, treatment or keys should be rejected")
+	}
+
+	store.Set("feature", "on", nil)
+	store.Set("feature", "off", &config, "key1", "key2")
+	store.Set("feature", "v2", nil, "key3")
+	config = "modified"
+
+	treatment, forcedConfig, ok := store.Get("feature", "key1")
+	if !ok || treatment != "off" || forcedConfig == nil || *forcedConfig != "{\"color\": \"red\"}" {
+		t.Error("Override of key1 should take precedence and keep its config")
+	}
+	if treatment, _, ok := store.Get("feature", "other"); !ok || treatment != "on" {
+		t.Error("Override for every key should be applied to other keys")
+	}
+	if _, _, ok := store.Get("another", "key1"); ok {
+		t.Error("Features without overrides should not be forced")
+	}
+
+	store.Set("another", "on", nil, "key1")
+	list := store.List()
+	if len(list) != 4 {
+		t.Error("Four overrides should be listed. Actual:", list)
+		return
+	}
+	if list[0].Feature != "another" || list[1].Treatment != "on" || len(list[1].Keys) != 0 {
+		t.Error("Overrides should be sorted by feature, the one for every key first")
+	}
+	if list[2].Treatment != "off" || len(list[2].Keys) != 2 || list[2].Keys[0] != "key1" || list[2].Keys[1] != "key2" {
+		t.Error("Keys sharing treatment and config should be grouped")
+	}
+
+	store.Clear("feature")
+	if _, _, ok := store.Get("feature", "key1"); ok {
+		t.Error("Overrides of the feature should be cleared")
+	}
+	store.ClearAll()
+	if len(store.List()) != 0 {
+		t.Error("Every override should be cleared")
+	}
+}
@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/storage"
+	"github.com/splitio/go-toolkit/datastructures/set"
+)
+
+// Version is the version of the snapshot format written by this package
+const Version = 1
+
+// Snapshot struct holding the exact split definitions and segment contents an sdk is evaluating with
+type Snapshot struct {
+	Version            int              `json:"version"`
+	SplitsChangeNumber int64            `json:"splitsChangeNumber"`
+	Splits             []dtos.SplitDTO  `json:"splits"`
+	Segments           []SegmentContent `json:"segments"`
+}
+
+// SegmentContent struct holding the keys of a segment
+type SegmentContent struct {
+	Name         string   `json:"name"`
+	ChangeNumber int64    `json:"changeNumber"`
+	Keys         []string `json:"keys"`
+}
+
+// Build takes a snapshot of the splits and the segments they reference
+func Build(splitStorage storage.SplitStorageConsumer, segmentStorage storage.SegmentStorageConsumer) (*Snapshot, error) {
+	splitsChangeNumber, err := splitStorage.ChangeNumber()
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching splits change number: %s", err.Error())
+	}
+
+	splits := splitStorage.All()
+	sort.Slice(splits, func(i, j int) bool { return splits[i].Name < splits[j].Name })
+
+	segmentNames := make([]string, 0)
+	for _, name := range splitStorage.SegmentNames().List() {
+		if segmentName, ok := name.(string); ok {
+			segmentNames = append(segmentNames, segmentName)
+		}
+	}
+	sort.Strings(segmentNames)
+
+	segments := make([]SegmentContent, 0, len(segmentNames))
+	for _, name := range segmentNames {
+		keys := segmentStorage.Keys(name)
+		if keys == nil {
+			continue
+		}
+		changeNumber, err := segmentStorage.ChangeNumber(name)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching change number of segment %s: %s", name, err.Error())
+		}
+		segments = append(segments, SegmentContent{
+			Name:         name,
+			ChangeNumber: changeNumber,
+			Keys:         toSortedStrings(keys),
+		})
+	}
+
+	return &Snapshot{
+		Version:            Version,
+		SplitsChangeNumber: splitsChangeNumber,
+		Splits:             splits,
+		Segments:           segments,
+	}, nil
+}
+
+// Write encodes the snapshot as JSON
+func (s *Snapshot) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// ParseError is returned by Read when the snapshot can't be decoded. Err keeps the error of the JSON decoder,
+// such as a *json.SyntaxError or a *json.UnmarshalTypeError holding the offset of the problem
+type ParseError struct {
+	Err error
+}
+
+// Error returns the description of the problem
+func (e *ParseError) Error() string {
+	return "Error parsing snapshot: " + e.Err.Error()
+}
+
+// Read decodes a JSON snapshot, failing if it was written with an unsupported version
+func Read(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	err := json.NewDecoder(r).Decode(&snapshot)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	if snapshot.Version < 1 || snapshot.Version > Version {
+		return nil, fmt.Errorf("Unsupported snapshot version %d. Supported versions are up to %d", snapshot.Version, Version)
+	}
+	return &snapshot, nil
+}
+
+// LoadSegments stores the segment contents of the snapshot, replacing the keys previously stored for them
+func (s *Snapshot) LoadSegments(segmentStorage storage.SegmentStorage) error {
+	for _, segment := range s.Segments {
+		toAdd := set.NewSet()
+		for _, key := range segment.Keys {
+			toAdd.Add(key)
+		}
+		toRemove := set.NewSet()
+		if current := segmentStorage.Keys(segment.Name); current != nil {
+			for _, key := range current.List() {
+				if !toAdd.Has(key) {
+					toRemove.Add(key)
+				}
+			}
+		}
+		err := segmentStorage.Update(segment.Name, toAdd, toRemove, segment.ChangeNumber)
+		if err != nil {
+			return fmt.Errorf("Error storing segment %s: %s", segment.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func toSortedStrings(keys *set.ThreadUnsafeSet) []string {
+	result := make([]string, 0, keys.Size())
+	for _, key := range keys.List() {
+		if str, ok := key.(string); ok {
+			result = append(result, str)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
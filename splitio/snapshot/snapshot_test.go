@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/storage/mutexmap"
+	"github.com/splitio/go-toolkit/datastructures/set"
+)
+
+func splitWithSegment(name string, segment string) dtos.SplitDTO {
+	return dtos.SplitDTO{
+		Name:             name,
+		ChangeNumber:     10,
+		Status:           "ACTIVE",
+		DefaultTreatment: "off",
+		Conditions: []dtos.ConditionDTO{
+			{
+				MatcherGroup: dtos.MatcherGroupDTO{
+					Combiner: "AND",
+					Matchers: []dtos.MatcherDTO{
+						{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: segment}},
+					},
+				},
+				Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+			},
+		},
+	}
+}
+
+func TestBuildAndRead(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{splitWithSegment("split2", "employees"), splitWithSegment("split1", "beta")}, 20)
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	segmentStorage.Update("employees", set.NewSet("user2", "user1"), set.NewSet(), 30)
+
+	snapshot, err := Build(splitStorage, segmentStorage)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	if snapshot.Version != Version || snapshot.SplitsChangeNumber != 20 {
+		t.Error("Unexpected snapshot header", snapshot.Version, snapshot.SplitsChangeNumber)
+	}
+	if len(snapshot.Splits) != 2 || snapshot.Splits[0].Name != "split1" || snapshot.Splits[1].Name != "split2" {
+		t.Error("Splits should be sorted by name")
+	}
+	if len(snapshot.Segments) != 1 || snapshot.Segments[0].ChangeNumber != 30 || strings.Join(snapshot.Segments[0].Keys, ",") != "user1,user2" {
+		t.Error("Only stored segments should be exported with sorted keys. Actual:", snapshot.Segments)
+	}
+
+	var buffer bytes.Buffer
+	err = snapshot.Write(&buffer)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+	}
+	read, err := Read(&buffer)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+		return
+	}
+	if len(read.Splits) != 2 || read.Splits[1].Conditions[0].MatcherGroup.Matchers[0].UserDefinedSegment.SegmentName != "employees" {
+		t.Error("Splits should be read back unchanged")
+	}
+
+	_, err = Read(strings.NewReader("{\"version\": 99}"))
+	if err == nil || err.Error() != "Unsupported snapshot version 99. Supported versions are up to 1" {
+		t.Error("Unsupported versions should be rejected. Actual:", err)
+	}
+	_, err = Read(strings.NewReader("{"))
+	if err == nil {
+		t.Error("Invalid JSON should be rejected")
+	}
+}
+
+func TestLoadSegments(t *testing.T) {
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	segmentStorage.Update("employees", set.NewSet("user1", "user3"), set.NewSet(), 1)
+
+	snapshot := &Snapshot{
+		Version:  Version,
+		Segments: []SegmentContent{{Name: "employees", ChangeNumber: 5, Keys: []string{"user1", "user2"}}},
+	}
+	err := snapshot.LoadSegments(segmentStorage)
+	if err != nil {
+		t.Error("It should not return error. Actual:", err)
+	}
+
+	keys := segmentStorage.Keys("employees")
+	if keys.Size() != 2 || !keys.Has("user1") || !keys.Has("user2") {
+		t.Error("Segment keys should be replaced. Actual:", keys.List())
+	}
+	if changeNumber, _ := segmentStorage.ChangeNumber("employees"); changeNumber != 5 {
+		t.Error("Segment change number should be updated. Actual:", changeNumber)
+	}
+}
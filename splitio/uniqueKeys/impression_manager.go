@@ -0,0 +1,41 @@
+package uniquekeys
+
+import (
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/provisional"
+)
+
+// ImpressionManager is the provisional.ImpressionManager used in "none" impressions mode. No impression is
+// handed back for logging, they are only counted and their keys tracked
+type ImpressionManager struct {
+	counter         *provisional.ImpressionsCounter
+	tracker         *Tracker
+	listenerEnabled bool
+}
+
+// NewImpressionManager instantiates a new ImpressionManager. Counter and tracker are optional
+func NewImpressionManager(counter *provisional.ImpressionsCounter, tracker *Tracker, listenerEnabled bool) *ImpressionManager {
+	return &ImpressionManager{
+		counter:         counter,
+		tracker:         tracker,
+		listenerEnabled: listenerEnabled,
+	}
+}
+
+// ProcessImpressions counts impressions and tracks their keys. Returns no impressions to log and,
+// if the listener is enabled, every impression for the listener
+func (m *ImpressionManager) ProcessImpressions(impressions []dtos.Impression) ([]dtos.Impression, []dtos.Impression) {
+	for _, impression := range impressions {
+		if m.counter != nil {
+			m.counter.Inc(impression.FeatureName, impression.Time, 1)
+		}
+		if m.tracker != nil {
+			m.tracker.Track(impression.FeatureName, impression.KeyName)
+		}
+	}
+
+	if m.listenerEnabled {
+		return nil, impressions
+	}
+	return nil, nil
+}
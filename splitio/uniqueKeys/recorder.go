@@ -0,0 +1,85 @@
+package uniquekeys
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/splitio/go-toolkit/asynctask"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const uniqueKeysPath = "/keys/ss"
+
+// KeysDTO struct mapping the keys evaluated for a feature
+type KeysDTO struct {
+	Feature string   `json:"f"`
+	Keys    []string `json:"ks"`
+}
+
+// BulkDTO struct mapping the payload sent to the unique keys endpoint
+type BulkDTO struct {
+	Keys []KeysDTO `json:"keys"`
+}
+
+// Poster is the subset of the HTTP client used to submit unique keys
+type Poster interface {
+	Post(service string, body []byte, headers map[string]string) error
+}
+
+// Recorder submits the tracked unique keys to split servers
+type Recorder struct {
+	tracker *Tracker
+	client  Poster
+	logger  logging.LoggerInterface
+}
+
+// NewRecorder instantiates a new Recorder
+func NewRecorder(tracker *Tracker, client Poster, logger logging.LoggerInterface) *Recorder {
+	return &Recorder{
+		tracker: tracker,
+		client:  client,
+		logger:  logger,
+	}
+}
+
+// SynchronizeUniqueKeys pops every tracked key and posts them
+func (r *Recorder) SynchronizeUniqueKeys() error {
+	tracked := r.tracker.PopAll()
+	if len(tracked) == 0 {
+		return nil
+	}
+
+	bulk := BulkDTO{Keys: make([]KeysDTO, 0, len(tracked))}
+	for feature, keys := range tracked {
+		sort.Strings(keys)
+		bulk.Keys = append(bulk.Keys, KeysDTO{Feature: feature, Keys: keys})
+	}
+	sort.Slice(bulk.Keys, func(i, j int) bool { return bulk.Keys[i].Feature < bulk.Keys[j].Feature })
+
+	data, err := json.Marshal(bulk)
+	if err != nil {
+		r.logger.Error("Error marshalling unique keys", err.Error())
+		return err
+	}
+
+	err = r.client.Post(uniqueKeysPath, data, nil)
+	if err != nil {
+		r.logger.Error("Error posting unique keys", err.Error())
+		return err
+	}
+	return nil
+}
+
+// NewRecordUniqueKeysTask creates a task that periodically submits the tracked unique keys,
+// submitting the remaining ones when stopped
+func NewRecordUniqueKeysTask(recorder *Recorder, period int, logger logging.LoggerInterface) *asynctask.AsyncTask {
+	record := func(logger logging.LoggerInterface) error {
+		return recorder.SynchronizeUniqueKeys()
+	}
+
+	onStop := func(logger logging.LoggerInterface) {
+		recorder.SynchronizeUniqueKeys()
+	}
+
+	return asynctask.NewAsyncTask("SubmitUniqueKeys", record, period, nil, onStop, logger)
+}
@@ -0,0 +1,87 @@
+package uniquekeys
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/splitio/go-split-commons/dtos"
+	"github.com/splitio/go-split-commons/provisional"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+type posterMock struct {
+	PostCall func(service string, body []byte, headers map[string]string) error
+}
+
+func (p *posterMock) Post(service string, body []byte, headers map[string]string) error {
+	return p.PostCall(service, body, headers)
+}
+
+func TestImpressionManager(t *testing.T) {
+	counter := provisional.NewImpressionsCounter()
+	tracker := NewTracker(10, nil)
+	impressions := []dtos.Impression{
+		{FeatureName: "feature1", KeyName: "key1", Time: 1},
+		{FeatureName: "feature1", KeyName: "key2", Time: 1},
+		{FeatureName: "feature1", KeyName: "key1", Time: 1},
+	}
+
+	forLog, forListener := NewImpressionManager(counter, tracker, true).ProcessImpressions(impressions)
+	if len(forLog) != 0 || len(forListener) != 3 {
+		t.Error("No impression should be logged and all of them should reach the listener")
+	}
+	if tracker.Size() != 2 {
+		t.Error("Unique keys should be tracked")
+	}
+	counts := counter.PopAll()
+	if len(counts) != 1 {
+		t.Error("Impressions should be counted")
+	}
+	for _, count := range counts {
+		if count != 3 {
+			t.Error("Every impression should be counted. Actual:", count)
+		}
+	}
+
+	forLog, forListener = NewImpressionManager(nil, nil, false).ProcessImpressions(impressions)
+	if forLog != nil || forListener != nil {
+		t.Error("No impressions should be returned when the listener is disabled")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	tracker := NewTracker(10, nil)
+	var posted BulkDTO
+	calls := 0
+	poster := &posterMock{
+		PostCall: func(service string, body []byte, headers map[string]string) error {
+			calls++
+			if service != "/keys/ss" {
+				t.Error("Unexpected path", service)
+			}
+			return json.Unmarshal(body, &posted)
+		},
+	}
+	recorder := NewRecorder(tracker, poster, logging.NewLogger(nil))
+
+	if recorder.SynchronizeUniqueKeys() != nil || calls != 0 {
+		t.Error("Nothing should be posted when there are no keys")
+	}
+
+	tracker.Track("feature2", "key1")
+	tracker.Track("feature1", "key2")
+	tracker.Track("feature1", "key1")
+	if recorder.SynchronizeUniqueKeys() != nil || calls != 1 {
+		t.Error("Keys should be posted")
+	}
+	if len(posted.Keys) != 2 || posted.Keys[0].Feature != "feature1" || len(posted.Keys[0].Keys) != 2 || posted.Keys[0].Keys[0] != "key1" || posted.Keys[1].Feature != "feature2" {
+		t.Error("Unexpected payload", posted)
+	}
+
+	tracker.Track("feature1", "key1")
+	poster.PostCall = func(service string, body []byte, headers map[string]string) error { return errors.New("some") }
+	if recorder.SynchronizeUniqueKeys() == nil {
+		t.Error("Error should be returned")
+	}
+}
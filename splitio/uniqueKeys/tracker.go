@@ -0,0 +1,96 @@
+// Package uniquekeys contains the components used to track which keys were evaluated for each feature
+// when impressions are not being recorded
+package uniquekeys
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Tracker keeps, for each feature, the set of keys it was evaluated for
+type Tracker struct {
+	keys    map[string]map[string]struct{}
+	size    int
+	maxSize int
+	dropped int64
+	onFull  func()
+	mutex   sync.Mutex
+}
+
+// NewTracker instantiates a new Tracker holding up to maxSize keys across all features.
+// onFull (optional) is called when the limit is reached so that keys can be flushed ahead of schedule
+func NewTracker(maxSize int, onFull func()) *Tracker {
+	return &Tracker{
+		keys:    make(map[string]map[string]struct{}),
+		maxSize: maxSize,
+		onFull:  onFull,
+	}
+}
+
+// Track records that a key was evaluated for a feature. Returns false if the key was discarded
+// because the tracker is full
+func (t *Tracker) Track(feature string, key string) bool {
+	t.mutex.Lock()
+	keys, ok := t.keys[feature]
+	if _, tracked := keys[key]; ok && tracked {
+		t.mutex.Unlock()
+		return true
+	}
+	if t.maxSize > 0 && t.size >= t.maxSize {
+		t.mutex.Unlock()
+		atomic.AddInt64(&t.dropped, 1)
+		t.notifyFull()
+		return false
+	}
+	if !ok {
+		keys = make(map[string]struct{})
+		t.keys[feature] = keys
+	}
+	keys[key] = struct{}{}
+	t.size++
+	full := t.maxSize > 0 && t.size >= t.maxSize
+	t.mutex.Unlock()
+
+	if full {
+		t.notifyFull()
+	}
+	return true
+}
+
+// notifyFull calls the onFull hook if one was set
+func (t *Tracker) notifyFull() {
+	if t.onFull != nil {
+		t.onFull()
+	}
+}
+
+// PopAll returns every tracked key grouped by feature and empties the tracker
+func (t *Tracker) PopAll() map[string][]string {
+	t.mutex.Lock()
+	tracked := t.keys
+	t.keys = make(map[string]map[string]struct{})
+	t.size = 0
+	t.mutex.Unlock()
+
+	result := make(map[string][]string, len(tracked))
+	for feature, keys := range tracked {
+		list := make([]string, 0, len(keys))
+		for key := range keys {
+			list = append(list, key)
+		}
+		result[feature] = list
+	}
+	return result
+}
+
+// Size returns how many keys are currently being held
+func (t *Tracker) Size() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.size
+}
+
+// Dropped returns how many keys were discarded because the tracker was full
+func (t *Tracker) Dropped() int64 {
+	return atomic.LoadInt64(&t.dropped)
+}
@@ -0,0 +1,40 @@
+package uniquekeys
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	fullCalls := 0
+	tracker := NewTracker(3, func() { fullCalls++ })
+
+	tracker.Track("feature1", "key1")
+	tracker.Track("feature1", "key1")
+	tracker.Track("feature1", "key2")
+	if tracker.Size() != 2 || fullCalls != 0 {
+		t.Error("Repeated keys should be tracked once")
+	}
+
+	if !tracker.Track("feature2", "key1") || fullCalls != 1 {
+		t.Error("onFull should be called when reaching the limit")
+	}
+	if tracker.Track("feature2", "key2") || tracker.Dropped() != 1 || fullCalls != 2 {
+		t.Error("Keys exceeding the limit should be dropped")
+	}
+	if !tracker.Track("feature1", "key1") {
+		t.Error("Already tracked keys should not be dropped")
+	}
+
+	tracked := tracker.PopAll()
+	sort.Strings(tracked["feature1"])
+	if len(tracked) != 2 || len(tracked["feature1"]) != 2 || tracked["feature1"][1] != "key2" || len(tracked["feature2"]) != 1 {
+		t.Error("Unexpected keys", tracked)
+	}
+	if tracker.Size() != 0 || len(tracker.PopAll()) != 0 {
+		t.Error("Tracker should be empty after popping")
+	}
+	if !tracker.Track("feature2", "key2") {
+		t.Error("Keys should be tracked again after popping")
+	}
+}